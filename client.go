@@ -16,14 +16,20 @@ type Logger interface {
 	Logf(format string, a ...interface{})
 }
 
-// Client is a connection to a stats service.
+// Client is a connection to a stats service.  It's an event.Source, like an event.Reader of a log.
 type Client struct {
 	*websocket.Conn
-	wmutex *sync.Mutex
-	rmutex *sync.Mutex
-	log    Logger
+	// SkipDemo drops the events of the cabinet's attract mode demo, as recognized by a DemoFilter.
+	// Events that fail to parse are still returned.
+	SkipDemo bool
+	wmutex   *sync.Mutex
+	rmutex   *sync.Mutex
+	log      Logger
+	demo     DemoFilter
 }
 
+var _ event.Source = (*Client)(nil)
+
 // NewClient connects to a stats service, and returns a *Client.
 func NewClient(addr string, l Logger) (*Client, error) {
 	u := url.URL{Scheme: "ws", Host: addr}
@@ -56,13 +62,32 @@ func (c *Client) WriteMessage(messageType int, data []byte) error {
 
 // GetEvent returns the next event from the stats service.
 func (c *Client) GetEvent() (event.Event, error) {
+	_, ev, err := c.GetRawEvent()
+	return ev, err
+}
+
+// GetRawEvent is like GetEvent, but also returns the event text as it was sent by the stats service.
+// The text is returned even when it fails to parse, so it can still be recorded, and is empty when reading from the connection fails.
+func (c *Client) GetRawEvent() (string, event.Event, error) {
+	for {
+		line, ev, err := c.getRawEvent()
+		if err == nil && c.SkipDemo && c.demo.Demo(ev) {
+			continue
+		}
+		return line, ev, err
+	}
+}
+
+// getRawEvent reads and parses the next event from the stats service.
+func (c *Client) getRawEvent() (string, event.Event, error) {
 	_, message, err := c.ReadMessage()
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
-	ev, err := event.Parse(string(message))
+	line := string(message)
+	ev, err := event.ParseBytes(message)
 	if err != nil {
-		return ev, err
+		return line, ev, err
 	}
 	// Auto reply to keep alives as a convenience, while still allowing the caller to see the event.
 	if _, ok := ev.(event.Alive); ok {
@@ -73,7 +98,7 @@ func (c *Client) GetEvent() (event.Event, error) {
 			}
 		}()
 	}
-	return ev, nil
+	return line, ev, nil
 }
 
 // Close does a graceful close of the websocket connection.
@@ -66,3 +66,35 @@ func clientServer(t *testing.T, r io.Reader) (*Client, *kqstatd.Replay) {
 	}
 	return cl, replay
 }
+
+func TestClient_SkipDemo(t *testing.T) {
+	t.Parallel()
+	r := strings.NewReader(`![k[playernames],v[,,,,,,,,,]]!
+![k[gamestart],v[map_day,False,0,True]]!
+![k[playerKill],v[1190,860,8,5,Worker]]!
+![k[gameend],v[map_day,False,30.5,True]]!
+![k[victory],v[Red,military]]!
+![k[playernames],v[a,b,c,d,e,f,g,h,i,j]]!
+![k[gamestart],v[map_night,False,0,False]]!
+![k[playerKill],v[750,861,1,2,Queen]]!
+![k[victory],v[Gold,military]]!
+`)
+	cl, _ := clientServer(t, r)
+	cl.SkipDemo = true
+	want := []string{"playernames", "playernames", "gamestart", "playerKill", "victory"}
+	for i := 0; i < 2*len(want); i++ {
+		ev, err := cl.GetEvent()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k := event.Key(ev); k != want[i%len(want)] {
+			t.Errorf("event %d: got %s want %s", i, k, want[i%len(want)])
+		}
+		if v, ok := ev.(event.GameStart); ok && v.Attract {
+			t.Errorf("event %d: got the demo's gamestart", i)
+		}
+		if v, ok := ev.(event.Victory); ok && v.Team != event.Gold {
+			t.Errorf("event %d: got the demo's victory", i)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/zone"
+)
+
+// formatter writes events in an output format.
+// The time an event was received is the zero time when it's unknown, such as for events read from raw logs.
+type formatter interface {
+	Format(t time.Time, line string, ev event.Event) error
+}
+
+// newFormatter returns the formatter for the named output format.
+// When zones is set, text output names the zone of the map each event occurred in.
+func newFormatter(name string, w io.Writer, color bool, zones *zone.Tracker) (formatter, error) {
+	switch name {
+	case "text":
+		return &textFormat{w: w, color: color, zones: zones}, nil
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetEscapeHTML(false)
+		return &jsonFormat{enc: enc}, nil
+	case "csv":
+		return &csvFormat{w: csv.NewWriter(w)}, nil
+	case "raw":
+		return &rawFormat{w: w}, nil
+	case "go":
+		return &goFormat{w: w}, nil
+	}
+	return nil, fmt.Errorf("unknown format: %s", name)
+}
+
+// textFormat writes a line of human readable text for each event.
+type textFormat struct {
+	w     io.Writer
+	color bool
+	zones *zone.Tracker
+}
+
+// ANSI escape codes used to color text output by team.
+const (
+	colorGold  = "\x1b[33m"
+	colorBlue  = "\x1b[34m"
+	colorRed   = "\x1b[31m"
+	colorReset = "\x1b[0m"
+)
+
+func (f *textFormat) Format(t time.Time, line string, ev event.Event) error {
+	text := fmt.Sprintf("%-16s %s", displayKey(ev), describe(ev))
+	if f.zones != nil {
+		if z, ok := f.zones.Locate(ev); ok {
+			text += " (" + z.String() + ")"
+		}
+	}
+	if !t.IsZero() {
+		text = t.Format("15:04:05.000") + " " + text
+	}
+	if f.color {
+		switch event.TeamOf(ev) {
+		case event.Gold:
+			text = colorGold + text + colorReset
+		case event.Blue:
+			text = colorBlue + text + colorReset
+		case event.Red:
+			text = colorRed + text + colorReset
+		}
+	}
+	_, err := fmt.Fprintln(f.w, text)
+	return err
+}
+
+// displayKey returns the event key of ev as shown by every format, without the trailing ": " of the snail keys.
+func displayKey(ev event.Event) string {
+	return strings.TrimSuffix(event.Key(ev), ": ")
+}
+
+// jsonFormat writes a JSON object for each event.
+type jsonFormat struct {
+	enc *json.Encoder
+}
+
+func (f *jsonFormat) Format(t time.Time, line string, ev event.Event) error {
+	var ts *time.Time
+	if !t.IsZero() {
+		ts = &t
+	}
+	return f.enc.Encode(struct {
+		Time  *time.Time  `json:"time,omitempty"`
+		Key   string      `json:"key"`
+		Event event.Event `json:"event"`
+	}{ts, displayKey(ev), ev})
+}
+
+// csvFormat writes a CSV record for each event, preceded by a header.
+type csvFormat struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (f *csvFormat) Format(t time.Time, line string, ev event.Event) error {
+	if !f.header {
+		f.header = true
+		err := f.w.Write([]string{"time", "key", "x", "y", "who", "target", "team", "detail"})
+		if err != nil {
+			return err
+		}
+	}
+	var ts string
+	if !t.IsZero() {
+		ts = t.Format(time.RFC3339Nano)
+	}
+	err := f.w.Write(append([]string{ts, displayKey(ev)}, fields(ev)...))
+	if err != nil {
+		return err
+	}
+	f.w.Flush()
+	return f.w.Error()
+}
+
+// rawFormat writes each event as it was sent by the stats service.
+type rawFormat struct {
+	w io.Writer
+}
+
+func (f *rawFormat) Format(t time.Time, line string, ev event.Event) error {
+	_, err := fmt.Fprintln(f.w, strings.TrimRight(line, "\r\n"))
+	return err
+}
+
+// goFormat writes the Go syntax representation of each event.
+type goFormat struct {
+	w io.Writer
+}
+
+func (f *goFormat) Format(t time.Time, line string, ev event.Event) error {
+	_, err := fmt.Fprintf(f.w, "%#v\n", ev)
+	return err
+}
+
+// describe returns a human readable description of ev.
+func describe(ev event.Event) string {
+	switch v := ev.(type) {
+	case event.Alive:
+		return v.Time
+	case event.BerryDeposit:
+		return fmt.Sprintf("%s deposited a berry at %d,%d", v.Who, v.X, v.Y)
+	case event.BerryKickIn:
+		return fmt.Sprintf("%s kicked in a berry at %d,%d", v.Who, v.X, v.Y)
+	case event.BlessMaiden:
+		return fmt.Sprintf("%s took the gate at %d,%d", v.Owner(), v.X, v.Y)
+	case event.CarryFood:
+		return fmt.Sprintf("%s picked up a berry", v.Who)
+	case event.GameEnd:
+		if v.Attract {
+			return fmt.Sprintf("demo ended on %s (%s) after %s", v.Map, v.Orientation, v.Duration)
+		}
+		return fmt.Sprintf("game ended on %s (%s) after %s", v.Map, v.Orientation, v.Duration)
+	case event.GameStart:
+		if v.Attract {
+			return fmt.Sprintf("demo started on %s (%s)", v.Map, v.Orientation)
+		}
+		return fmt.Sprintf("game started on %s (%s)", v.Map, v.Orientation)
+	case event.GetOffSnail:
+		return fmt.Sprintf("%s got off the snail at %d,%d", v.Who, v.X, v.Y)
+	case event.GetOnSnail:
+		return fmt.Sprintf("%s got on the snail at %d,%d", v.Who, v.X, v.Y)
+	case event.Glance:
+		return fmt.Sprintf("%s glanced off %s", v.Attacker, v.Target)
+	case event.PlayerKill:
+		return fmt.Sprintf("%s killed %s (%s) at %d,%d", v.Slayer, v.Slain, v.SlainClass, v.X, v.Y)
+	case event.PlayerNames:
+		return strings.Join(v, ", ")
+	case event.ReserveMaiden:
+		return fmt.Sprintf("%s entered the gate at %d,%d", v.Who, v.X, v.Y)
+	case event.SnailEat:
+		return fmt.Sprintf("%s is feeding %s to the snail at %d,%d", v.Rider, v.Meal, v.X, v.Y)
+	case event.SnailEscape:
+		return fmt.Sprintf("%s escaped the snail at %d,%d", v.Who, v.X, v.Y)
+	case event.Spawn:
+		if v.IsAI {
+			return fmt.Sprintf("%s spawned as AI", v.Who)
+		}
+		return fmt.Sprintf("%s spawned", v.Who)
+	case event.UnreserveMaiden:
+		return fmt.Sprintf("%s left the gate at %d,%d", v.Who, v.X, v.Y)
+	case event.UseMaiden:
+		return fmt.Sprintf("%s used %s at %d,%d", v.Who, v.Buff, v.X, v.Y)
+	case event.Victory:
+		return fmt.Sprintf("%s won by %s", v.Team, v.Type)
+	}
+	return fmt.Sprintf("%#v", ev)
+}
+
+// fields returns the x, y, who, target, team and detail columns of the CSV format for ev.
+func fields(ev event.Event) []string {
+	var (
+		x, y, who, target, detail string
+		tm                        = string(event.TeamOf(ev))
+	)
+	xy := func(vx, vy int) {
+		x, y = strconv.Itoa(vx), strconv.Itoa(vy)
+	}
+	switch v := ev.(type) {
+	case event.Alive:
+		detail = v.Time
+	case event.BerryDeposit:
+		xy(v.X, v.Y)
+		who = v.Who.String()
+	case event.BerryKickIn:
+		xy(v.X, v.Y)
+		who = v.Who.String()
+	case event.BlessMaiden:
+		xy(v.X, v.Y)
+	case event.CarryFood:
+		who = v.Who.String()
+	case event.GameEnd:
+		detail = fmt.Sprintf("%s %s %s", v.Map, v.Orientation, v.Duration)
+		if v.Attract {
+			detail += " attract"
+		}
+	case event.GameStart:
+		detail = fmt.Sprintf("%s %s", v.Map, v.Orientation)
+		if v.Attract {
+			detail += " attract"
+		}
+	case event.GetOffSnail:
+		xy(v.X, v.Y)
+		who = v.Who.String()
+	case event.GetOnSnail:
+		xy(v.X, v.Y)
+		who = v.Who.String()
+	case event.Glance:
+		who, target = v.Attacker.String(), v.Target.String()
+	case event.PlayerKill:
+		xy(v.X, v.Y)
+		who, target = v.Slayer.String(), v.Slain.String()
+		detail = string(v.SlainClass)
+	case event.PlayerNames:
+		detail = strings.Join(v, ",")
+	case event.ReserveMaiden:
+		xy(v.X, v.Y)
+		who = v.Who.String()
+	case event.SnailEat:
+		xy(v.X, v.Y)
+		who, target = v.Rider.String(), v.Meal.String()
+	case event.SnailEscape:
+		xy(v.X, v.Y)
+		who = v.Who.String()
+	case event.Spawn:
+		who = v.Who.String()
+		detail = strconv.FormatBool(v.IsAI)
+	case event.UnreserveMaiden:
+		xy(v.X, v.Y)
+		who = v.Who.String()
+	case event.UseMaiden:
+		xy(v.X, v.Y)
+		who = v.Who.String()
+		detail = string(v.Buff)
+	case event.Victory:
+		detail = string(v.Type)
+	}
+	return []string{x, y, who, target, tm, detail}
+}
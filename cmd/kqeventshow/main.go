@@ -1,39 +1,191 @@
 // kqeventshow connects to a Killerqueen stats service, and prints recieved events.
+//
+// When given file arguments, it prints the events in those recordings instead, where the file - is stdin.
+// Recordings may be in the format written by kqrecord, or raw event text like the logs in testdata.
 package main
 
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"net"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/rickyninja/kqstat"
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/record"
+	"github.com/rickyninja/kqstat/zone"
 )
 
 func main() {
 	logger := newMylog(log.New(os.Stderr, "", 0))
 	var (
-		port string
-		host string
+		port      string
+		host      string
+		format    string
+		only      string
+		exclude   string
+		hideAlive bool
+		color     bool
+		zones     bool
+		skipDemo  bool
 	)
 	flag.StringVar(&port, "port", "12749", "Killerqueen stats service port")
 	flag.StringVar(&host, "host", "localhost", "Killerqueen stats service host")
+	flag.StringVar(&format, "format", "text", "output format: text, json, csv, raw or go")
+	flag.StringVar(&only, "only", "", "comma separated event keys to show, e.g. playerKill,victory")
+	flag.StringVar(&exclude, "exclude", "", "comma separated event keys to hide")
+	flag.BoolVar(&hideAlive, "hide-alive", false, "hide keep alive events")
+	flag.BoolVar(&color, "color", false, "color text output by team")
+	flag.BoolVar(&skipDemo, "skip-demo", false, "hide events of the attract mode demo")
+	flag.BoolVar(&zones, "zones", false, "name the zone of the map each event occurred in, for text output")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [recording...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
 	flag.Parse()
 
+	// The tracker sees every event, even filtered ones, so it knows the map of the current game.
+	var tracker *zone.Tracker
+	if zones {
+		tracker = zone.NewTracker(zone.Builtin())
+	}
+	f, err := newFormatter(format, os.Stdout, color, tracker)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(1)
+	}
+	filt := newFilter(only, exclude)
+	if hideAlive {
+		filt.exclude["alive"] = true
+	}
+	if skipDemo {
+		filt.demo = new(kqstat.DemoFilter)
+	}
+
+	if flag.NArg() > 0 {
+		for _, path := range flag.Args() {
+			err := showFile(path, f, filt, tracker, logger)
+			if err != nil {
+				log.Fatalf("%s: %s", path, err)
+			}
+		}
+		return
+	}
+
 	cl, err := kqstat.NewClient(net.JoinHostPort(host, port), logger)
 	if err != nil {
 		log.Fatal(err)
 	}
 	for {
-		ev, err := cl.GetEvent()
+		line, ev, err := cl.GetRawEvent()
+		if line == "" {
+			log.Fatal(err)
+		}
+		if err != nil {
+			logger.Logf("%s", err)
+			continue
+		}
+		if tracker != nil {
+			tracker.Add(ev)
+		}
+		if !filt.allow(ev) {
+			continue
+		}
+		err = f.Format(time.Now(), line, ev)
 		if err != nil {
 			log.Fatal(err)
 		}
-		fmt.Printf("%#v\n", ev)
 	}
 }
 
+// showFile prints the events in the recording at path, or stdin when path is -.
+// The tracker, which may be nil, is given every event.
+func showFile(path string, f formatter, filt *filter, tracker *zone.Tracker, logger *mylog) error {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		fd, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		in = fd
+	}
+	r := record.NewReader(in)
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if env.Err != nil {
+			logger.Logf("%s: event %d: %s", path, env.Seq, env.Err)
+			continue
+		}
+		if tracker != nil {
+			tracker.Add(env.Event)
+		}
+		if !filt.allow(env.Event) {
+			continue
+		}
+		err = f.Format(env.Time, env.Line, env.Event)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// filter decides which events are shown by their keys.
+type filter struct {
+	only    map[string]bool
+	exclude map[string]bool
+	// demo hides the events of the demo when set.
+	demo *kqstat.DemoFilter
+}
+
+// newFilter creates a filter from comma separated lists of event keys.
+func newFilter(only, exclude string) *filter {
+	return &filter{
+		only:    keySet(only),
+		exclude: keySet(exclude),
+	}
+}
+
+// allow reports if ev should be shown.  It must be given every event, so the demo can be followed.
+func (f *filter) allow(ev event.Event) bool {
+	if f.demo != nil && f.demo.Demo(ev) {
+		return false
+	}
+	key := normalizeKey(event.Key(ev))
+	if len(f.only) > 0 && !f.only[key] {
+		return false
+	}
+	return !f.exclude[key]
+}
+
+// keySet splits a comma separated list of event keys into a set.
+func keySet(list string) map[string]bool {
+	set := make(map[string]bool)
+	for _, k := range strings.Split(list, ",") {
+		k = normalizeKey(k)
+		if k != "" {
+			set[k] = true
+		}
+	}
+	return set
+}
+
+// normalizeKey makes event keys comparable regardless of case, or the trailing ": " on some keys.
+func normalizeKey(k string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(k), ":"))
+}
+
 type mylog struct {
 	*log.Logger
 }
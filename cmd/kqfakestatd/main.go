@@ -18,10 +18,15 @@ func main() {
 		host     string
 		port     string
 		statfile string
+		ka       = kqstatd.DefaultKeepAlive
 	)
 	flag.StringVar(&host, "host", "", "host of the killerqueen stat service")
 	flag.StringVar(&port, "port", "12749", "port of the killerqueen stat service")
 	flag.StringVar(&statfile, "statfile", "", "stat file to simulate killerqueen stat service")
+	flag.DurationVar(&ka.Interval, "keepalive", ka.Interval, "how often to send keep alive events, 0 disables them")
+	flag.DurationVar(&ka.Grace, "keepalive-grace", ka.Grace, "how long clients have to reply to a keep alive event")
+	flag.IntVar(&ka.MaxMissed, "keepalive-missed", ka.MaxMissed, "how many keep alive replies may be missed before disconnecting")
+	flag.StringVar(&ka.Format, "keepalive-format", ka.Format, "time layout of keep alive event values")
 	flag.Parse()
 	if statfile == "" {
 		fmt.Fprintln(os.Stderr, "statfile is required")
@@ -37,6 +42,7 @@ func main() {
 	if err != nil {
 		logger.Fatal("Failed NewReplay: ", err)
 	}
+	replay.KeepAlive = ka
 	http.ListenAndServe(net.JoinHostPort(host, port), replay)
 }
 
@@ -0,0 +1,217 @@
+// kqheatmap renders heatmaps or scatter plots of where events happened in recorded games.
+//
+// Plots can be split by map, team, player and event with -by, which writes a file for each combination.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/heatmap"
+)
+
+func main() {
+	logger := log.New(os.Stderr, "", 0)
+	var (
+		out        string
+		kind       string
+		format     string
+		by         string
+		events     string
+		background string
+		filter     heatmap.Filter
+		opts       heatmap.Options
+	)
+	flag.StringVar(&out, "out", ".", "directory to write plots in")
+	flag.StringVar(&kind, "kind", "heatmap", "kind of plot, heatmap or scatter")
+	flag.StringVar(&format, "format", "png", "image format, png or svg")
+	flag.StringVar(&by, "by", "", "comma separated fields to split plots by: map, team, player and event")
+	flag.StringVar(&events, "events", "", "comma separated event keys to plot, e.g. playerKill,berryDeposit; all when empty")
+	flag.StringVar((*string)(&filter.Map), "map", "", "only plot games on this map, e.g. map_day")
+	flag.StringVar((*string)(&filter.Team), "team", "", "only plot events of this team, Gold or Blue")
+	flag.StringVar(&filter.Player, "player", "", "only plot events of this player name or position, e.g. gold-queen")
+	flag.StringVar(&background, "background", "", "map image to draw behind the plot, with blue on the left")
+	flag.IntVar(&opts.Width, "width", heatmap.Width/2, "plot width in pixels")
+	flag.IntVar(&opts.Height, "height", heatmap.Height/2, "plot height in pixels")
+	flag.Float64Var(&opts.Radius, "radius", 30, "how far each event spreads, in map pixels")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] recording...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if kind != "heatmap" && kind != "scatter" {
+		fmt.Fprintf(os.Stderr, "kind must be heatmap or scatter, got %s\n", kind)
+		os.Exit(1)
+	}
+	if format != "png" && format != "svg" {
+		fmt.Fprintf(os.Stderr, "format must be png or svg, got %s\n", format)
+		os.Exit(1)
+	}
+	if events != "" {
+		filter.Keys = strings.Split(events, ",")
+	}
+	if filter.Team != "" {
+		var err error
+		filter.Team, err = event.ParseTeam(string(filter.Team), true)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if background != "" {
+		if format == "svg" {
+			opts.BackgroundHref = background
+		} else {
+			img, err := loadImage(background)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			opts.Background = img
+		}
+	}
+
+	var points []heatmap.Point
+	for _, path := range flag.Args() {
+		games, err := game.SplitFile(path)
+		if err != nil {
+			logger.Fatalf("%s: %s", path, err)
+		}
+		for _, g := range games {
+			points = append(points, heatmap.Points(g)...)
+		}
+	}
+	points = heatmap.Select(points, filter)
+	groups, err := group(points, by)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	err = os.MkdirAll(out, 0755)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		path := filepath.Join(out, kind+name+"."+format)
+		err := writePlot(path, kind, format, groups[name], opts)
+		if err != nil {
+			logger.Fatalf("%s: %s", path, err)
+		}
+		logger.Printf("Plotted %d events in %s", len(groups[name]), path)
+	}
+}
+
+// group splits points by the comma separated fields in by, and returns them by file name suffix.
+func group(points []heatmap.Point, by string) (map[string][]heatmap.Point, error) {
+	var fields []string
+	for _, f := range strings.Split(by, ",") {
+		f = strings.TrimSpace(f)
+		switch f {
+		case "":
+			continue
+		case "map", "team", "player", "event":
+			fields = append(fields, f)
+		default:
+			return nil, fmt.Errorf("can't split plots by %s, only by map, team, player and event", f)
+		}
+	}
+	groups := make(map[string][]heatmap.Point)
+	if len(fields) == 0 {
+		groups[""] = points
+		return groups, nil
+	}
+	for _, p := range points {
+		var name string
+		for _, f := range fields {
+			var v string
+			switch f {
+			case "map":
+				v = strings.TrimPrefix(string(p.Map), "map_")
+			case "team":
+				v = strings.ToLower(string(p.Team))
+			case "player":
+				v = p.Name
+				if v == "" && p.Who != 0 {
+					v = p.Who.String()
+				}
+			case "event":
+				v = p.Key
+			}
+			if v == "" {
+				v = "none"
+			}
+			name += "-" + safeName(v)
+		}
+		groups[name] = append(groups[name], p)
+	}
+	return groups, nil
+}
+
+// safeName replaces characters that don't belong in a file name.
+func safeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', ' ', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, s)
+}
+
+// writePlot writes a plot of points to the file at path.
+func writePlot(path, kind, format string, points []heatmap.Point, opts heatmap.Options) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = plot(f, kind, format, points, opts)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// plot writes a plot of points to w.
+func plot(w io.Writer, kind, format string, points []heatmap.Point, opts heatmap.Options) error {
+	switch {
+	case kind == "heatmap" && format == "svg":
+		return heatmap.HeatmapSVG(w, points, opts)
+	case kind == "scatter" && format == "svg":
+		return heatmap.ScatterSVG(w, points, opts)
+	case kind == "heatmap":
+		return png.Encode(w, heatmap.Heatmap(points, opts))
+	default:
+		return png.Encode(w, heatmap.Scatter(points, opts))
+	}
+}
+
+// loadImage decodes the PNG or JPEG image at path.
+func loadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	return img, err
+}
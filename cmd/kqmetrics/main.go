@@ -0,0 +1,80 @@
+// kqmetrics monitors cabinets, and serves their metrics to Prometheus at /metrics.
+//
+// Cabinets are given as name=host:port arguments, and the port defaults to 12749.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rickyninja/kqstat/metrics"
+)
+
+func main() {
+	logger := newMylog(log.New(os.Stderr, "", log.LstdFlags))
+	var (
+		listen string
+		retry  time.Duration
+	)
+	flag.StringVar(&listen, "listen", ":9749", "address to serve metrics on")
+	flag.DurationVar(&retry, "retry", 5*time.Second, "how long to wait before reconnecting to a cabinet")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] name=host[:port]...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	handler := metrics.NewHandler()
+	stop := make(chan struct{})
+	for _, arg := range flag.Args() {
+		name, addr, err := parseCabinet(arg)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			flag.Usage()
+			os.Exit(1)
+		}
+		c := metrics.NewCollector(name)
+		handler.Add(c)
+		m := metrics.NewMonitor(addr, c, logger)
+		m.Retry = retry
+		go m.Run(stop)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", handler)
+	logger.Fatal(http.ListenAndServe(listen, mux))
+}
+
+// parseCabinet parses a name=host[:port] argument.
+func parseCabinet(arg string) (name, addr string, err error) {
+	i := strings.IndexByte(arg, '=')
+	if i < 1 || i == len(arg)-1 {
+		return "", "", fmt.Errorf("cabinet must be name=host[:port], got %s", arg)
+	}
+	name, addr = arg[:i], arg[i+1:]
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "12749")
+	}
+	return name, addr, nil
+}
+
+type mylog struct {
+	*log.Logger
+}
+
+func newMylog(l *log.Logger) *mylog {
+	return &mylog{l}
+}
+
+func (l *mylog) Logf(format string, a ...interface{}) {
+	l.Printf(format, a...)
+}
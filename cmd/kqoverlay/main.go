@@ -0,0 +1,82 @@
+// kqoverlay connects to a Killerqueen stats service, and serves stream overlay pages for OBS browser sources.
+//
+// The pages show a live scoreboard with player names, queen lives, berries, snail progress, gate control
+// and the set score, updated over a websocket.  Each page is a template that can be overridden by a file of
+// the same name in the -templates directory; run with -dump to write the built-in templates there to start from.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/relay"
+)
+
+func main() {
+	logger := newMylog(log.New(os.Stderr, "", log.LstdFlags))
+	var (
+		host      string
+		port      string
+		listen    string
+		templates string
+		dump      bool
+		title     string
+		bestOf    int
+		rotation  string
+		retry     time.Duration
+	)
+	flag.StringVar(&host, "host", "localhost", "Killerqueen stats service host")
+	flag.StringVar(&port, "port", "12749", "Killerqueen stats service port")
+	flag.StringVar(&listen, "listen", ":12752", "address to serve overlay pages on")
+	flag.StringVar(&templates, "templates", "", "directory of templates overriding the built-in ones")
+	flag.BoolVar(&dump, "dump", false, "write the built-in templates to the templates directory, and exit")
+	flag.StringVar(&title, "title", "Killer Queen", "title shown on the scoreboard")
+	flag.IntVar(&bestOf, "best-of", 3, "number of games in a set")
+	flag.StringVar(&rotation, "rotation", "", "comma separated maps played in each set, e.g. day,night,dusk")
+	flag.DurationVar(&retry, "retry", 5*time.Second, "how long to wait before reconnecting to the stats service")
+	flag.Parse()
+
+	if dump {
+		if templates == "" {
+			logger.Fatal("dump requires a templates directory")
+		}
+		err := dumpTemplates(templates)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		return
+	}
+	maps, err := game.ParseRotation(rotation)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	r := relay.New(logger)
+	r.Sets = game.NewSetTracker(bestOf, maps...)
+	go r.Run(net.JoinHostPort(host, port), retry, make(chan struct{}))
+
+	p := &pages{dir: templates, data: pageData{Title: title}, log: logger}
+	mux := http.NewServeMux()
+	rh := r.Handler()
+	mux.Handle("/ws", rh)
+	mux.Handle("/events", rh)
+	mux.Handle("/state", rh)
+	mux.Handle("/", p)
+	logger.Fatal(http.ListenAndServe(listen, mux))
+}
+
+type mylog struct {
+	*log.Logger
+}
+
+func newMylog(l *log.Logger) *mylog {
+	return &mylog{l}
+}
+
+func (l *mylog) Logf(format string, a ...interface{}) {
+	l.Printf(format, a...)
+}
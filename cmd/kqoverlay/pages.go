@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// pageData is passed to every HTML template.
+type pageData struct {
+	// Title is shown on the scoreboard.
+	Title string
+}
+
+// pages serves the overlay pages, and the files they use.
+type pages struct {
+	// dir is a directory of templates overriding the built-in ones, and may be empty.
+	dir  string
+	data pageData
+	log  *mylog
+}
+
+// ServeHTTP does http.Handler.
+func (p *pages) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	name := path.Base(req.URL.Path)
+	if req.URL.Path == "/" {
+		name = "index.html"
+	}
+	text, err := p.load(name)
+	if os.IsNotExist(err) {
+		http.NotFound(rw, req)
+		return
+	}
+	if err != nil {
+		p.log.Logf("%s: %s", name, err)
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ct := mime.TypeByExtension(path.Ext(name))
+	if ct != "" {
+		rw.Header().Set("Content-Type", ct)
+	}
+	if path.Ext(name) != ".html" {
+		rw.Write([]byte(text))
+		return
+	}
+	// Templates are parsed on every request, so edits to overrides show up on reload.
+	t, err := template.New(name).Parse(text)
+	if err != nil {
+		p.log.Logf("%s: %s", name, err)
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var buf bytes.Buffer
+	err = t.Execute(&buf, p.data)
+	if err != nil {
+		p.log.Logf("%s: %s", name, err)
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Write(buf.Bytes())
+}
+
+// load returns the named template from the override directory, or the built-in one when it isn't overridden.
+func (p *pages) load(name string) (string, error) {
+	if p.dir != "" {
+		b, err := ioutil.ReadFile(filepath.Join(p.dir, name))
+		if err == nil {
+			return string(b), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	text, ok := builtin[name]
+	if !ok {
+		return "", os.ErrNotExist
+	}
+	return text, nil
+}
+
+// dumpTemplates writes the built-in templates to dir, without overwriting any that exist.
+func dumpTemplates(dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	for name, text := range builtin {
+		f, err := os.OpenFile(filepath.Join(dir, name), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if os.IsExist(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = f.WriteString(text)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		err = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package main
+
+// builtin are the built-in templates, by name.  HTML templates are executed with pageData, and the rest are served as is.
+var builtin = map[string]string{
+	"index.html":      indexHTML,
+	"scoreboard.html": scoreboardHTML,
+	"overlay.css":     overlayCSS,
+	"overlay.js":      overlayJS,
+}
+
+const indexHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} overlays</title>
+<link rel="stylesheet" href="/overlay.css">
+</head>
+<body class="index">
+<h1>{{.Title}} overlays</h1>
+<p>Add a page as a browser source in OBS, sized 1920x1080 with a transparent background.</p>
+<ul>
+<li><a href="/scoreboard.html">Scoreboard</a>: player names, queen lives, berries, snail, gates and set score</li>
+<li><a href="/state">Game state</a> as JSON</li>
+</ul>
+</body>
+</html>
+`
+
+const scoreboardHTML = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} scoreboard</title>
+<link rel="stylesheet" href="/overlay.css">
+</head>
+<body class="scoreboard">
+<div id="board">
+  <div class="team" id="gold">
+    <div class="lives"></div>
+    <div class="hive"><div class="hive-fill"></div></div>
+    <div class="stats">Berries <span class="berries">0</span> &middot; Gates <span class="gates">0</span></div>
+    <ol class="players">
+      <li class="player"></li><li class="player"></li><li class="player"></li><li class="player"></li><li class="player"></li>
+    </ol>
+  </div>
+  <div id="middle">
+    <div id="title">{{.Title}}</div>
+    <div id="status">Waiting for a game</div>
+    <div id="set"></div>
+    <div id="snail-track"><div id="snail"></div></div>
+  </div>
+  <div class="team" id="blue">
+    <div class="lives"></div>
+    <div class="hive"><div class="hive-fill"></div></div>
+    <div class="stats">Berries <span class="berries">0</span> &middot; Gates <span class="gates">0</span></div>
+    <ol class="players">
+      <li class="player"></li><li class="player"></li><li class="player"></li><li class="player"></li><li class="player"></li>
+    </ol>
+  </div>
+</div>
+<script src="/overlay.js"></script>
+</body>
+</html>
+`
+
+const overlayCSS = `body {
+  margin: 0;
+  font-family: "Helvetica Neue", Arial, sans-serif;
+  color: #fff;
+  background: transparent;
+}
+body.index {
+  background: #222;
+  padding: 2em;
+}
+body.index a {
+  color: #fc0;
+}
+#board {
+  display: flex;
+  justify-content: space-between;
+  align-items: flex-start;
+  padding: 12px 24px;
+}
+body.blue-on-left #board {
+  flex-direction: row-reverse;
+}
+.team {
+  width: 420px;
+  padding: 8px 12px;
+  border-radius: 6px;
+  background: rgba(0, 0, 0, 0.65);
+}
+#gold {
+  border-top: 4px solid #f5b800;
+}
+#blue {
+  border-top: 4px solid #2f7cf6;
+}
+.lives {
+  font-size: 28px;
+  letter-spacing: 4px;
+}
+#gold .lives {
+  color: #f5b800;
+}
+#blue .lives {
+  color: #2f7cf6;
+}
+.hive {
+  height: 14px;
+  margin: 6px 0;
+  border-radius: 7px;
+  background: rgba(255, 255, 255, 0.2);
+  overflow: hidden;
+}
+.hive-fill {
+  width: 0;
+  height: 100%;
+  background: #c0265f;
+  transition: width 0.3s;
+}
+.stats {
+  font-size: 14px;
+}
+.players {
+  margin: 6px 0 0;
+  padding-left: 0;
+  list-style: none;
+  font-size: 16px;
+}
+.players li:first-child {
+  font-weight: bold;
+}
+#middle {
+  width: 560px;
+  text-align: center;
+  padding: 8px;
+  border-radius: 6px;
+  background: rgba(0, 0, 0, 0.65);
+}
+#title {
+  font-size: 22px;
+  font-weight: bold;
+}
+#status, #set {
+  font-size: 16px;
+  margin-top: 4px;
+}
+#snail-track {
+  position: relative;
+  height: 16px;
+  margin-top: 8px;
+  border-radius: 8px;
+  background: rgba(255, 255, 255, 0.2);
+}
+#snail {
+  position: absolute;
+  top: 0;
+  left: 50%;
+  width: 16px;
+  height: 16px;
+  margin-left: -8px;
+  border-radius: 8px;
+  background: #ccc;
+  transition: left 0.3s;
+}
+#snail.gold {
+  background: #f5b800;
+}
+#snail.blue {
+  background: #2f7cf6;
+}
+`
+
+const overlayJS = `(function () {
+  "use strict";
+
+  // A team wins economically with 12 berries, and militarily by killing the other queen 3 times.
+  var BERRIES_TO_WIN = 12;
+  var QUEEN_LIVES = 3;
+  var MAP_WIDTH = 1920;
+  var MAP_NAMES = {map_day: "Day", map_night: "Night", map_dusk: "Dusk", map_twilight: "Twilight", map_bonus_military: "Military Bonus", map_bonus_snail: "Snail Bonus"};
+
+  function repeat(s, n) {
+    var out = "";
+    for (var i = 0; i < n; i++) {
+      out += s;
+    }
+    return out;
+  }
+
+  function renderTeam(state, name, first) {
+    var team = state[name];
+    var el = document.getElementById(name);
+    var players = el.querySelectorAll(".player");
+    for (var i = 0; i < players.length; i++) {
+      // Gold positions are odd and blue positions are even, so each team's players alternate.
+      var p = state.players[first + 2 * i];
+      players[i].textContent = p.name || p.position;
+    }
+    var lives = Math.max(0, QUEEN_LIVES - team.queen_deaths);
+    el.querySelector(".lives").textContent = repeat("♛", lives) + repeat("♕", QUEEN_LIVES - lives);
+    // Kick ins are counted for the kicker's team.
+    var berries = team.berries + team.kick_ins;
+    el.querySelector(".hive-fill").style.width = Math.min(100, 100 * berries / BERRIES_TO_WIN) + "%";
+    el.querySelector(".berries").textContent = berries + " / " + BERRIES_TO_WIN;
+    el.querySelector(".gates").textContent = team.gates;
+  }
+
+  function renderSet(set) {
+    var el = document.getElementById("set");
+    if (!set) {
+      el.textContent = "";
+      return;
+    }
+    var names = [set.sides[0] ? set.sides[0].join(", ") : "", set.sides[1] ? set.sides[1].join(", ") : ""];
+    var colors = set.gold_side === 0 ? ["Gold", "Blue"] : ["Blue", "Gold"];
+    var text = (names[0] || colors[0]) + " " + set.wins[0] + " - " + set.wins[1] + " " + (names[1] || colors[1]);
+    if (set.best_of > 0) {
+      text += " (best of " + set.best_of + ")";
+    }
+    el.textContent = text;
+  }
+
+  function render(state) {
+    renderTeam(state, "gold", 0);
+    renderTeam(state, "blue", 1);
+    document.body.classList.toggle("blue-on-left", state.orientation === "BLUE_ON_LEFT");
+    var status = "Waiting for a game";
+    if (state.victory) {
+      status = state.victory.Team + " wins by " + state.victory.Type;
+    } else if (state.in_game) {
+      status = MAP_NAMES[state.map] || state.map || "In game";
+    }
+    document.getElementById("status").textContent = status;
+    renderSet(state.set);
+    var snail = document.getElementById("snail");
+    var x = state.snail ? state.snail.x : MAP_WIDTH / 2;
+    snail.style.left = (100 * x / MAP_WIDTH) + "%";
+    snail.className = state.snail && state.snail.rider ? state.snail.team.toLowerCase() : "";
+  }
+
+  function connect() {
+    var scheme = location.protocol === "https:" ? "wss:" : "ws:";
+    var ws = new WebSocket(scheme + "//" + location.host + "/ws");
+    ws.onmessage = function (e) {
+      var m = JSON.parse(e.data);
+      if (m.type === "state") {
+        render(m.state);
+      }
+    };
+    ws.onclose = function () {
+      setTimeout(connect, 2000);
+    };
+  }
+
+  connect();
+})();
+`
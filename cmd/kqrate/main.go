@@ -0,0 +1,133 @@
+// kqrate computes player and team ratings from a directory of recordings, and saves them to a file.
+// Given -in, it updates previously saved ratings with the recordings instead of starting from scratch.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/player"
+	"github.com/rickyninja/kqstat/rating"
+)
+
+func main() {
+	logger := log.New(os.Stderr, "", 0)
+	var (
+		dir    string
+		roster string
+		in     string
+		out    string
+		k      float64
+	)
+	flag.StringVar(&dir, "dir", "", "directory of recordings to rate, searched recursively")
+	flag.StringVar(&roster, "roster", "", "roster file mapping names to players")
+	flag.StringVar(&in, "in", "", "saved ratings to update, which may be the same file as -out; the recordings shouldn't include games they already rate")
+	flag.StringVar(&out, "out", "ratings.json", "file to save ratings to")
+	flag.Float64Var(&k, "k", rating.DefaultK, "how much a single game can move a rating")
+	flag.Parse()
+	if dir == "" {
+		fmt.Fprintln(os.Stderr, "dir is required")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var ro *player.Roster
+	if roster != "" {
+		var err error
+		ro, err = player.LoadRoster(roster)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+	reg, err := player.NewRegistry(ro)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	paths, err := recordings(dir)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	ratings := rating.New()
+	if in != "" {
+		ratings, err = rating.Load(in)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+	// Saved ratings keep the K they were computed with, unless it's given.
+	if in == "" || flagSet("k") {
+		ratings.K = k
+	}
+	rated, skipped := 0, 0
+	for _, path := range paths {
+		games, err := game.SplitFile(path)
+		if err != nil {
+			logger.Fatalf("%s: %s", path, err)
+		}
+		for _, g := range games {
+			res, ok := rating.NewResult(reg, g)
+			if !ok {
+				skipped++
+				continue
+			}
+			err = ratings.Record(res)
+			if err != nil {
+				logger.Fatalf("%s: %s", path, err)
+			}
+			rated++
+		}
+	}
+	logger.Printf("Rated %d games from %d recordings, skipped %d games without known players.", rated, len(paths), skipped)
+	err = ratings.Save(out)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "Player\tRating\tGames\tWins")
+	for _, id := range rating.Ranked(ratings.Players) {
+		r := ratings.Players[id]
+		fmt.Fprintf(tw, "%s\t%.0f\t%d\t%d\n", reg.Resolve(id).Name, r.Rating, r.Games, r.Wins)
+	}
+	fmt.Fprintln(tw, "\nTeam\tRating\tGames\tWins")
+	for _, id := range rating.Ranked(ratings.Teams) {
+		r := ratings.Teams[id]
+		fmt.Fprintf(tw, "%s\t%.0f\t%d\t%d\n", id, r.Rating, r.Games, r.Wins)
+	}
+	tw.Flush()
+}
+
+// flagSet reports if the named flag was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// recordings returns the paths of the recordings under dir, in lexical order.  Recordings may be gzipped.
+func recordings(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		switch filepath.Ext(path) {
+		case ".log", ".jsonl", ".gz":
+			if fi.Mode().IsRegular() {
+				paths = append(paths, path)
+			}
+		}
+		return nil
+	})
+	sort.Strings(paths)
+	return paths, err
+}
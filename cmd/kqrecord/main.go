@@ -0,0 +1,106 @@
+// kqrecord connects to a Killerqueen stats service, records every event to disk, and re-serves them to other clients.
+//
+// Run as "kqrecord split recording..." to split recordings into a file per game instead.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/rickyninja/kqstat"
+	"github.com/rickyninja/kqstat/record"
+)
+
+func main() {
+	logger := newMylog(log.New(os.Stderr, "", log.LstdFlags))
+	if len(os.Args) > 1 && os.Args[1] == "split" {
+		split(logger, os.Args[2:])
+		return
+	}
+	var (
+		host   string
+		port   string
+		dir    string
+		rotate string
+		listen string
+		retry  time.Duration
+		header record.Header
+	)
+	flag.StringVar(&host, "host", "localhost", "Killerqueen stats service host")
+	flag.StringVar(&port, "port", "12749", "Killerqueen stats service port")
+	flag.StringVar(&dir, "dir", ".", "directory to write recordings in")
+	flag.StringVar(&rotate, "rotate", "game", "start a new recording each game or night")
+	flag.StringVar(&listen, "listen", ":12750", "address to re-serve events on, empty to disable")
+	flag.StringVar(&header.Cabinet, "cabinet", "", "name of the cabinet, saved in each recording")
+	flag.StringVar(&header.Scene, "scene", "", "name of the scene, saved in each recording")
+	flag.DurationVar(&retry, "retry", 5*time.Second, "how long to wait before reconnecting to the stats service")
+	flag.Parse()
+
+	var rot record.Rotation
+	switch rotate {
+	case "game":
+		rot = record.PerGame
+	case "night":
+		rot = record.PerNight
+	default:
+		fmt.Fprintf(os.Stderr, "rotate must be game or night, got %s\n", rotate)
+		flag.Usage()
+		os.Exit(1)
+	}
+	rec, err := record.NewRecorder(dir, rot, header, logger)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer rec.Close()
+	hub := record.NewHub(logger)
+	if listen != "" {
+		go func() {
+			logger.Fatal(http.ListenAndServe(listen, hub))
+		}()
+	}
+
+	addr := net.JoinHostPort(host, port)
+	for {
+		cl, err := kqstat.NewClient(addr, logger)
+		if err != nil {
+			logger.Printf("Failed to connect to %s: %s", addr, err)
+			time.Sleep(retry)
+			continue
+		}
+		logger.Printf("Connected to %s", addr)
+		for {
+			line, ev, err := cl.GetRawEvent()
+			if line == "" {
+				logger.Printf("GetRawEvent: %s", err)
+				break
+			}
+			if err != nil {
+				logger.Printf("%s", err)
+			}
+			err = rec.Record(time.Now(), line, ev)
+			if err != nil {
+				logger.Fatal(err)
+			}
+			hub.Publish(line)
+		}
+		cl.Conn.Close()
+		time.Sleep(retry)
+	}
+}
+
+type mylog struct {
+	*log.Logger
+}
+
+func newMylog(l *log.Logger) *mylog {
+	return &mylog{l}
+}
+
+func (l *mylog) Logf(format string, a ...interface{}) {
+	l.Printf(format, a...)
+}
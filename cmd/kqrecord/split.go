@@ -0,0 +1,97 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/record"
+)
+
+// split writes each game in the recordings named by args to its own recording.
+func split(logger *mylog, args []string) {
+	fs := flag.NewFlagSet("split", flag.ExitOnError)
+	var (
+		dir    string
+		header record.Header
+	)
+	fs.StringVar(&dir, "dir", ".", "directory to write games in")
+	fs.StringVar(&header.Cabinet, "cabinet", "", "name of the cabinet, overriding the one in each recording")
+	fs.StringVar(&header.Scene, "scene", "", "name of the scene, overriding the one in each recording")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s split [flags] recording...\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	for _, path := range fs.Args() {
+		n, err := splitFile(path, dir, header)
+		if err != nil {
+			logger.Fatalf("Failed to split %s: %s", path, err)
+		}
+		logger.Printf("Split %d games from %s", n, path)
+	}
+}
+
+// splitFile splits the recording at path into games written in dir, and returns how many games were written.
+// The path - reads a recording from stdin.
+func splitFile(path, dir string, header record.Header) (int, error) {
+	var in io.Reader = os.Stdin
+	base := "stdin"
+	if path != "-" {
+		fd, err := os.Open(path)
+		if err != nil {
+			return 0, err
+		}
+		defer fd.Close()
+		in = fd
+		base = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	r := record.NewReader(in)
+	games, err := game.Split(r)
+	if err != nil {
+		return 0, err
+	}
+	h := r.Header()
+	if header.Cabinet != "" {
+		h.Cabinet = header.Cabinet
+	}
+	if header.Scene != "" {
+		h.Scene = header.Scene
+	}
+	for i, g := range games {
+		name := filepath.Join(dir, fmt.Sprintf("%s-game-%02d.jsonl", base, i+1))
+		fd, err := os.Create(name)
+		if err != nil {
+			return i, err
+		}
+		// Each game gets its own copy of the recording's Meta, so the keys added for one don't leak into the next.
+		gh := h
+		gh.Meta = make(map[string]string, len(h.Meta)+2)
+		for k, v := range h.Meta {
+			gh.Meta[k] = v
+		}
+		gh.Meta["game"] = strconv.Itoa(i + 1)
+		gh.Meta["source"] = filepath.Base(path)
+		err = g.Write(fd, gh)
+		if cerr := fd.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(games), nil
+}
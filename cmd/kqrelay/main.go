@@ -0,0 +1,46 @@
+// kqrelay connects to a Killerqueen stats service, and republishes its events as JSON to browser clients.
+//
+// Clients connect with Server-Sent Events at /events, a websocket at /ws, or fetch the game state at /state.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/rickyninja/kqstat/relay"
+)
+
+func main() {
+	logger := newMylog(log.New(os.Stderr, "", log.LstdFlags))
+	var (
+		host   string
+		port   string
+		listen string
+		retry  time.Duration
+	)
+	flag.StringVar(&host, "host", "localhost", "Killerqueen stats service host")
+	flag.StringVar(&port, "port", "12749", "Killerqueen stats service port")
+	flag.StringVar(&listen, "listen", ":12751", "address to serve clients on")
+	flag.DurationVar(&retry, "retry", 5*time.Second, "how long to wait before reconnecting to the stats service")
+	flag.Parse()
+
+	r := relay.New(logger)
+	go r.Run(net.JoinHostPort(host, port), retry, make(chan struct{}))
+	logger.Fatal(http.ListenAndServe(listen, r.Handler()))
+}
+
+type mylog struct {
+	*log.Logger
+}
+
+func newMylog(l *log.Logger) *mylog {
+	return &mylog{l}
+}
+
+func (l *mylog) Logf(format string, a ...interface{}) {
+	l.Printf(format, a...)
+}
@@ -0,0 +1,143 @@
+// kqreport prints a box score after each game, from a Killerqueen stats service or recordings of one.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"time"
+
+	"github.com/rickyninja/kqstat"
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/record"
+)
+
+func main() {
+	logger := newMylog(log.New(os.Stderr, "", 0))
+	var (
+		port     string
+		host     string
+		format   string
+		bestOf   int
+		rotation string
+	)
+	flag.StringVar(&port, "port", "12749", "Killerqueen stats service port")
+	flag.StringVar(&host, "host", "localhost", "Killerqueen stats service host")
+	flag.StringVar(&format, "format", "text", "output format: text, markdown or html")
+	flag.IntVar(&bestOf, "best-of", 3, "number of games in a set")
+	flag.StringVar(&rotation, "rotation", "", "comma separated maps played in each set, e.g. day,night,dusk")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [recording...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	rend, err := newRenderer(format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(1)
+	}
+	maps, err := game.ParseRotation(rotation)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		flag.Usage()
+		os.Exit(1)
+	}
+	rep := &reporter{
+		w:      os.Stdout,
+		render: rend,
+		sets:   game.NewSetTracker(bestOf, maps...),
+	}
+
+	if flag.NArg() > 0 {
+		for _, path := range flag.Args() {
+			err := rep.file(path)
+			if err != nil {
+				log.Fatalf("%s: %s", path, err)
+			}
+		}
+		return
+	}
+
+	cl, err := kqstat.NewClient(net.JoinHostPort(host, port), logger)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for {
+		line, ev, err := cl.GetRawEvent()
+		if line == "" {
+			log.Fatal(err)
+		}
+		if err != nil {
+			logger.Logf("%s", err)
+			continue
+		}
+		err = rep.add(record.Envelope{Time: time.Now(), Line: line, Event: ev})
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// reporter prints a box score for each game completed by the events added to it.
+type reporter struct {
+	w        io.Writer
+	render   renderer
+	splitter game.Splitter
+	sets     *game.SetTracker
+	games    int
+}
+
+// add adds the next event, printing a box score if it completes a game.
+func (r *reporter) add(env record.Envelope) error {
+	seg, ok := r.splitter.Add(env)
+	if !ok {
+		return nil
+	}
+	r.games++
+	set := r.sets.Add(seg)
+	return r.render(r.w, r.games, game.Summarize(seg), len(r.sets.Sets()), set)
+}
+
+// file adds each event in the recording at path, or stdin when path is -.
+func (r *reporter) file(path string) error {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		fd, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer fd.Close()
+		in = fd
+	}
+	rr := record.NewReader(in)
+	for {
+		env, err := rr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = r.add(env)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+type mylog struct {
+	*log.Logger
+}
+
+func newMylog(l *log.Logger) *mylog {
+	return &mylog{l}
+}
+
+func (l *mylog) Logf(format string, a ...interface{}) {
+	l.Printf(format, a...)
+}
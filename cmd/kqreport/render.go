@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/game"
+)
+
+// renderer prints the box score of a game.  n is the game's number among those reported, and set is the setNum'th set.
+type renderer func(w io.Writer, n int, s *game.Stats, setNum int, set *game.Set) error
+
+// newRenderer returns the renderer for the named output format.
+func newRenderer(name string) (renderer, error) {
+	switch name {
+	case "text":
+		return renderText, nil
+	case "markdown":
+		return renderMarkdown, nil
+	case "html":
+		return renderHTML, nil
+	}
+	return nil, fmt.Errorf("unknown format: %s", name)
+}
+
+// columns are the headings of the player table.
+var columns = []string{"Player", "Name", "Kills", "Deaths", "Queen kills", "Berries", "Kick-ins", "Snail"}
+
+// row returns the cells of the player table for p.
+func row(p game.PlayerStats) []string {
+	return []string{
+		p.Bee.String(),
+		p.Name,
+		fmt.Sprint(p.Kills),
+		fmt.Sprint(p.Deaths),
+		fmt.Sprint(p.QueenKills),
+		fmt.Sprint(p.Berries),
+		fmt.Sprint(p.KickIns),
+		fmt.Sprint(p.SnailDistance),
+	}
+}
+
+// summary returns the lines describing the game as a whole.
+func summary(n int, s *game.Stats, setNum int, set *game.Set) []string {
+	score := fmt.Sprintf("Set %d, game %d: %s", setNum, len(set.Games), set.Score())
+	if set.Winner >= 0 {
+		score += fmt.Sprintf(", won by side %d", set.Winner+1)
+	}
+	return []string{
+		fmt.Sprintf("Game %d: %s, %s, %s", n, mapName(s.Map), s.Orientation, s.Duration.Round(time.Second)),
+		fmt.Sprintf("Winner: %s by %s", s.Victory.Team, s.Victory.Type),
+		fmt.Sprintf("Queen deaths: Gold %d, Blue %d", s.QueenDeaths(event.Gold), s.QueenDeaths(event.Blue)),
+		fmt.Sprintf("Gates: Gold %d, Blue %d", s.Gates(event.Gold), s.Gates(event.Blue)),
+		score,
+	}
+}
+
+// mapName returns a friendly name for m, such as Night for map_night.
+func mapName(m event.Map) string {
+	name := strings.TrimPrefix(string(m), "map_")
+	if name == "" {
+		return "unknown map"
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// teams returns every player, with the gold team first.
+func teams(s *game.Stats) []game.PlayerStats {
+	return append(s.Team(event.Gold), s.Team(event.Blue)...)
+}
+
+func renderText(w io.Writer, n int, s *game.Stats, setNum int, set *game.Set) error {
+	for _, line := range summary(n, s, setNum, set) {
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	for _, p := range teams(s) {
+		fmt.Fprintln(tw, strings.Join(row(p), "\t"))
+	}
+	err := tw.Flush()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+func renderMarkdown(w io.Writer, n int, s *game.Stats, setNum int, set *game.Set) error {
+	lines := summary(n, s, setNum, set)
+	fmt.Fprintf(w, "### %s\n\n", lines[0])
+	for _, line := range lines[1:] {
+		fmt.Fprintf(w, "- %s\n", line)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "| %s |\n", strings.Join(columns, " | "))
+	fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(columns)))
+	for _, p := range teams(s) {
+		cells := row(p)
+		for i := range cells {
+			cells[i] = strings.Replace(cells[i], "|", `\|`, -1)
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<section class="kq-game">
+<h3>{{index .Summary 0}}</h3>
+<ul>{{range slice .Summary 1}}
+<li>{{.}}</li>{{end}}
+</ul>
+<table>
+<tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr>{{range .Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>{{end}}
+</table>
+</section>
+`))
+
+func renderHTML(w io.Writer, n int, s *game.Stats, setNum int, set *game.Set) error {
+	var rows [][]string
+	for _, p := range teams(s) {
+		rows = append(rows, row(p))
+	}
+	return htmlReport.Execute(w, struct {
+		Summary []string
+		Columns []string
+		Rows    [][]string
+	}{summary(n, s, setNum, set), columns, rows})
+}
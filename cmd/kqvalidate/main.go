@@ -0,0 +1,107 @@
+// kqvalidate checks recordings for anomalies, such as malformed lines and events that break the rules of the game,
+// and reports them with their line numbers.
+//
+// The file - is stdin.  It exits with status 1 when any anomalies are found, and 2 when a recording can't be read.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/rickyninja/kqstat/record"
+	"github.com/rickyninja/kqstat/validate"
+)
+
+func main() {
+	logger := log.New(os.Stderr, "", 0)
+	var format string
+	flag.StringVar(&format, "format", "text", "report format: text, or json for an object per anomaly")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] recording...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if format != "text" && format != "json" {
+		fmt.Fprintf(os.Stderr, "format must be text or json, got %s\n", format)
+		flag.Usage()
+		os.Exit(2)
+	}
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	// The event parser logs values it can't parse, which are reported as anomalies instead.
+	log.SetOutput(ioutil.Discard)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	counts := make(map[validate.Kind]int)
+	total := 0
+	for _, path := range flag.Args() {
+		found, err := validateFile(path)
+		if err != nil {
+			logger.Printf("%s: %s", path, err)
+			os.Exit(2)
+		}
+		for _, a := range found {
+			counts[a.Kind]++
+			total++
+			if format == "json" {
+				err = enc.Encode(struct {
+					File string `json:"file"`
+					validate.Anomaly
+				}{path, a})
+			} else if a.Text != "" {
+				_, err = fmt.Printf("%s: %s\n\t%s\n", path, a, a.Text)
+			} else {
+				_, err = fmt.Printf("%s: %s\n", path, a)
+			}
+			if err != nil {
+				logger.Fatal(err)
+			}
+		}
+	}
+	if format == "text" {
+		summarize(os.Stdout, counts, total)
+	}
+	if total > 0 {
+		os.Exit(1)
+	}
+}
+
+// validateFile returns the anomalies in the recording at path, or stdin when path is -.
+func validateFile(path string) ([]validate.Anomaly, error) {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		fd, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer fd.Close()
+		in = fd
+	}
+	return validate.Validate(record.NewReader(in))
+}
+
+// summarize writes the number of anomalies of each kind.
+func summarize(w io.Writer, counts map[validate.Kind]int, total int) {
+	if total == 0 {
+		fmt.Fprintln(w, "no anomalies found")
+		return
+	}
+	var kinds []string
+	for k := range counts {
+		kinds = append(kinds, string(k))
+	}
+	sort.Strings(kinds)
+	fmt.Fprintf(w, "%d anomalies found:\n", total)
+	for _, k := range kinds {
+		fmt.Fprintf(w, "%6d %s\n", counts[validate.Kind(k)], k)
+	}
+}
@@ -0,0 +1,29 @@
+package kqstat
+
+import "github.com/rickyninja/kqstat/event"
+
+// DemoFilter recognizes the events of the cabinet's attract mode demo in a stream of events, as they arrive.
+//
+// A demo begins at a gamestart flagged as attract mode, and lasts until the next gamestart or playernames event.
+// Games played only by AI can't be recognized until they end; see game.Segment.Kind for classifying whole games.
+type DemoFilter struct {
+	demo bool
+}
+
+// Demo reports if ev, the next event in the stream, is part of the demo.  Keep alives never are.
+func (f *DemoFilter) Demo(ev event.Event) bool {
+	switch v := ev.(type) {
+	case event.Alive:
+		return false
+	case event.GameStart:
+		f.demo = v.Attract
+	case event.PlayerNames:
+		f.demo = false
+	case event.Victory:
+		// Red isn't a team people play on, so only the demo can be won by it.
+		if v.Team == event.Red {
+			return true
+		}
+	}
+	return f.demo
+}
@@ -0,0 +1,135 @@
+package event
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Bees returns the ten player positions in order, from GoldQueen to BlueChecks.
+func Bees() []Bee {
+	return []Bee{GoldQueen, BlueQueen, GoldStripes, BlueStripes, GoldAbs, BlueAbs, GoldSkulls, BlueSkulls, GoldChecks, BlueChecks}
+}
+
+// Valid reports if b is one of the ten player positions.
+func (b Bee) Valid() bool {
+	return b >= GoldQueen && b <= BlueChecks
+}
+
+// Team returns the team of a player position, or an empty Team for an unknown position.
+// Gold positions are odd, and blue positions are even.
+func (b Bee) Team() Team {
+	if !b.Valid() {
+		return ""
+	}
+	if b%2 == 1 {
+		return Gold
+	}
+	return Blue
+}
+
+// IsQueen reports if b is either team's queen.
+func (b Bee) IsQueen() bool {
+	return b == GoldQueen || b == BlueQueen
+}
+
+// Role returns the name of a position without its team, such as queen or stripes, or an empty string for an unknown position.
+func (b Bee) Role() string {
+	if !b.Valid() {
+		return ""
+	}
+	return roles[(b-1)/2]
+}
+
+// roles are the names of each position without their team, in order.
+var roles = [5]string{"queen", "stripes", "abs", "skulls", "checks"}
+
+// Opponent returns the same position on the other team, such as BlueStripes for GoldStripes.
+// An unknown position is returned as is.
+func (b Bee) Opponent() Bee {
+	if !b.Valid() {
+		return b
+	}
+	if b%2 == 1 {
+		return b + 1
+	}
+	return b - 1
+}
+
+// ParseBee parses a position by the name its String method returns, such as gold-stripes, or by its number.
+func ParseBee(s string) (Bee, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if n, err := strconv.Atoi(s); err == nil {
+		if b := Bee(n); b.Valid() {
+			return b, nil
+		}
+		return 0, fmt.Errorf("unknown bee: %q", s)
+	}
+	for _, b := range Bees() {
+		if s == b.String() {
+			return b, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown bee: %q", s)
+}
+
+// Bees returns the positions on a team in order, with the queen first.  Only Gold and Blue have positions.
+func (t Team) Bees() []Bee {
+	var bees []Bee
+	for _, b := range Bees() {
+		if b.Team() == t {
+			bees = append(bees, b)
+		}
+	}
+	return bees
+}
+
+// Opponent returns the other team, or an empty Team when t is neither Gold nor Blue.
+func (t Team) Opponent() Team {
+	switch t {
+	case Gold:
+		return Blue
+	case Blue:
+		return Gold
+	}
+	return ""
+}
+
+// TeamOf returns the team an event is about, or an empty Team when it's about neither, such as a gamestart.
+// Events about a player are for the player's team, and the player who did something rather than who it was done to.
+// Gates are for the team that took them, so the cabinet's Red is Gold.
+func TeamOf(ev Event) Team {
+	switch v := ev.(type) {
+	case BerryDeposit:
+		return v.Who.Team()
+	case BerryKickIn:
+		return v.Who.Team()
+	case BlessMaiden:
+		return v.Owner()
+	case CarryFood:
+		return v.Who.Team()
+	case GetOffSnail:
+		return v.Who.Team()
+	case GetOnSnail:
+		return v.Who.Team()
+	case Glance:
+		return v.Attacker.Team()
+	case PlayerKill:
+		return v.Slayer.Team()
+	case ReserveMaiden:
+		return v.Who.Team()
+	case SnailEat:
+		return v.Rider.Team()
+	case SnailEscape:
+		return v.Who.Team()
+	case Spawn:
+		return v.Who.Team()
+	case UnreserveMaiden:
+		return v.Who.Team()
+	case UseMaiden:
+		return v.Who.Team()
+	case Victory:
+		return v.Team
+	}
+	return ""
+}
@@ -0,0 +1,117 @@
+package event
+
+import "testing"
+
+func TestBees(t *testing.T) {
+	t.Parallel()
+	bees := Bees()
+	if len(bees) != 10 {
+		t.Fatalf("got %d bees, want 10", len(bees))
+	}
+	for i, b := range bees {
+		if b != Bee(i+1) || !b.Valid() {
+			t.Errorf("bee %d is %d", i, b)
+		}
+		got, err := ParseBee(b.String())
+		if err != nil || got != b {
+			t.Errorf("ParseBee(%s) = %d, %v", b, got, err)
+		}
+		if o := b.Opponent(); o.Team() != b.Team().Opponent() || o.Role() != b.Role() || o.Opponent() != b {
+			t.Errorf("wrong opponent of %s: %s", b, o)
+		}
+		if b.IsQueen() != (b.Role() == "queen") {
+			t.Errorf("%s IsQueen is %t", b, b.IsQueen())
+		}
+	}
+	for _, b := range []Bee{0, 11, -1} {
+		if b.Valid() || b.Team() != "" || b.Role() != "" || b.IsQueen() || b.Opponent() != b {
+			t.Errorf("%d should be an unknown position", b)
+		}
+	}
+}
+
+func TestBeeTeam(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		bee  Bee
+		team Team
+		role string
+	}{
+		{GoldQueen, Gold, "queen"},
+		{BlueQueen, Blue, "queen"},
+		{GoldStripes, Gold, "stripes"},
+		{BlueAbs, Blue, "abs"},
+		{GoldSkulls, Gold, "skulls"},
+		{BlueChecks, Blue, "checks"},
+	}
+	for _, tc := range cases {
+		if got := tc.bee.Team(); got != tc.team {
+			t.Errorf("%s team is %s, want %s", tc.bee, got, tc.team)
+		}
+		if got := tc.bee.Role(); got != tc.role {
+			t.Errorf("%s role is %s, want %s", tc.bee, got, tc.role)
+		}
+	}
+	if got := Gold.Bees(); len(got) != 5 || got[0] != GoldQueen || got[4] != GoldChecks {
+		t.Errorf("wrong gold bees: %v", got)
+	}
+	if got := Blue.Bees(); len(got) != 5 || got[0] != BlueQueen || got[4] != BlueChecks {
+		t.Errorf("wrong blue bees: %v", got)
+	}
+	if got := Red.Bees(); len(got) != 0 {
+		t.Errorf("red shouldn't have bees: %v", got)
+	}
+	if Red.Opponent() != "" {
+		t.Errorf("red shouldn't have an opponent")
+	}
+}
+
+func TestParseBee(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		in   string
+		want Bee
+		ok   bool
+	}{
+		{"gold-stripes", GoldStripes, true},
+		{" Blue-Checks ", BlueChecks, true},
+		{"2", BlueQueen, true},
+		{"10", BlueChecks, true},
+		{"0", 0, false},
+		{"11", 0, false},
+		{"unknown-bee", 0, false},
+		{"", 0, false},
+	}
+	for _, tc := range cases {
+		got, err := ParseBee(tc.in)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("ParseBee(%q) = %d, %v", tc.in, got, err)
+		}
+	}
+}
+
+func TestTeamOf(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		line string
+		want Team
+	}{
+		{"![k[playerKill],v[830,860,1,10,Worker]]!", Gold},
+		{"![k[playerKill],v[1190,860,8,5,Worker]]!", Blue},
+		{"![k[snailEat],v[960,11,4,7]]!", Blue},
+		{"![k[blessMaiden],v[1510,860,Red]]!", Gold},
+		{"![k[blessMaiden],v[1510,860,Blue]]!", Blue},
+		{"![k[victory],v[Gold,military]]!", Gold},
+		{"![k[gamestart],v[map_day,False,0,False]]!", ""},
+		{"![k[alive],v[12:39:54 PM]]!", ""},
+	}
+	for _, tc := range cases {
+		ev, err := Parse(tc.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := TeamOf(ev); got != tc.want {
+			t.Errorf("TeamOf(%s) = %q, want %q", tc.line, got, tc.want)
+		}
+	}
+}
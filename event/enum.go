@@ -0,0 +1,161 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The enumerated types are strings holding the values sent by the stats service.  Each has a Valid method reporting if
+// it's a known value, a String method returning the value as sent, and a Parse function.
+//
+// In strict mode, the Parse functions return an error for unknown values.  Otherwise they're returned as is, so events
+// from newer cabinet software can still be read.  Known values are matched regardless of case in either mode.
+
+// Valid reports if t is a known team.
+func (t Team) Valid() bool {
+	switch t {
+	case Gold, Blue, Red:
+		return true
+	}
+	return false
+}
+
+func (t Team) String() string {
+	return string(t)
+}
+
+// ParseTeam parses the name of a team.
+func ParseTeam(s string, strict bool) (Team, error) {
+	for _, t := range []Team{Gold, Blue, Red} {
+		if strings.EqualFold(s, string(t)) {
+			return t, nil
+		}
+	}
+	return Team(s), unknown("team", s, strict)
+}
+
+// Valid reports if b is a known buff.
+func (b Buff) Valid() bool {
+	switch b {
+	case Wings, Speed:
+		return true
+	}
+	return false
+}
+
+func (b Buff) String() string {
+	return string(b)
+}
+
+// ParseBuff parses the name of a gate's buff.
+func ParseBuff(s string, strict bool) (Buff, error) {
+	for _, b := range []Buff{Wings, Speed} {
+		if strings.EqualFold(s, string(b)) {
+			return b, nil
+		}
+	}
+	return Buff(s), unknown("buff", s, strict)
+}
+
+// Valid reports if m is a known map.
+func (m Map) Valid() bool {
+	switch m {
+	case Day, Night, Dusk, Twilight, BonusMilitary, BonusSnail:
+		return true
+	}
+	return false
+}
+
+// Bonus reports if m is a bonus map.
+func (m Map) Bonus() bool {
+	return m == BonusMilitary || m == BonusSnail
+}
+
+func (m Map) String() string {
+	return string(m)
+}
+
+// ParseMap parses the name of a map, with or without its map_ prefix, such as map_day or day.
+func ParseMap(s string, strict bool) (Map, error) {
+	for _, m := range []Map{Day, Night, Dusk, Twilight, BonusMilitary, BonusSnail} {
+		if strings.EqualFold(s, string(m)) || strings.EqualFold(s, strings.TrimPrefix(string(m), "map_")) {
+			return m, nil
+		}
+	}
+	return Map(s), unknown("map", s, strict)
+}
+
+// Valid reports if o is a known orientation.
+func (o CabOrientation) Valid() bool {
+	return o == BlueOnLeft || o == GoldOnLeft
+}
+
+func (o CabOrientation) String() string {
+	return string(o)
+}
+
+// ParseCabOrientation parses the name of an orientation, such as BLUE_ON_LEFT.
+// The stats service sends orientation as whether gold is on the left, so True and False are accepted too.
+func ParseCabOrientation(s string, strict bool) (CabOrientation, error) {
+	switch {
+	case strings.EqualFold(s, string(BlueOnLeft)), strings.EqualFold(s, "False"):
+		return BlueOnLeft, nil
+	case strings.EqualFold(s, string(GoldOnLeft)), strings.EqualFold(s, "True"):
+		return GoldOnLeft, nil
+	}
+	return CabOrientation(s), unknown("orientation", s, strict)
+}
+
+// Valid reports if w is a known win condition.
+func (w WinCondition) Valid() bool {
+	switch w {
+	case Military, Economic, Snail:
+		return true
+	}
+	return false
+}
+
+func (w WinCondition) String() string {
+	return string(w)
+}
+
+// ParseWinCondition parses the name of a win condition.
+func ParseWinCondition(s string, strict bool) (WinCondition, error) {
+	for _, w := range []WinCondition{Military, Economic, Snail} {
+		if strings.EqualFold(s, string(w)) {
+			return w, nil
+		}
+	}
+	return WinCondition(s), unknown("win condition", s, strict)
+}
+
+// Valid reports if c is a known class.
+func (c Class) Valid() bool {
+	switch c {
+	case Worker, Soldier, Queen:
+		return true
+	}
+	return false
+}
+
+func (c Class) String() string {
+	return string(c)
+}
+
+// ParseClass parses the name of a class.
+func ParseClass(s string, strict bool) (Class, error) {
+	for _, c := range []Class{Worker, Soldier, Queen} {
+		if strings.EqualFold(s, string(c)) {
+			return c, nil
+		}
+	}
+	return Class(s), unknown("class", s, strict)
+}
+
+// unknown returns the error for an unknown value of an enumerated type, which is nil unless strict.
+func unknown(kind, s string, strict bool) error {
+	if !strict {
+		return nil
+	}
+	return fmt.Errorf("unknown %s: %q", kind, s)
+}
@@ -0,0 +1,142 @@
+package event
+
+import "testing"
+
+func TestEnumRoundTrip(t *testing.T) {
+	t.Parallel()
+	// Each value must be of its own type, not an untyped string, for these to compile.
+	teams := []Team{Gold, Blue, Red}
+	buffs := []Buff{Wings, Speed}
+	maps := []Map{Day, Night, Dusk, Twilight, BonusMilitary, BonusSnail}
+	orientations := []CabOrientation{BlueOnLeft, GoldOnLeft}
+	conditions := []WinCondition{Military, Economic, Snail}
+	classes := []Class{Worker, Soldier, Queen}
+
+	for _, v := range teams {
+		got, err := ParseTeam(v.String(), true)
+		if err != nil || got != v || !got.Valid() {
+			t.Errorf("ParseTeam(%q) = %q, %v", v, got, err)
+		}
+	}
+	for _, v := range buffs {
+		got, err := ParseBuff(v.String(), true)
+		if err != nil || got != v || !got.Valid() {
+			t.Errorf("ParseBuff(%q) = %q, %v", v, got, err)
+		}
+	}
+	for _, v := range maps {
+		got, err := ParseMap(v.String(), true)
+		if err != nil || got != v || !got.Valid() {
+			t.Errorf("ParseMap(%q) = %q, %v", v, got, err)
+		}
+	}
+	for _, v := range orientations {
+		got, err := ParseCabOrientation(v.String(), true)
+		if err != nil || got != v || !got.Valid() {
+			t.Errorf("ParseCabOrientation(%q) = %q, %v", v, got, err)
+		}
+	}
+	for _, v := range conditions {
+		got, err := ParseWinCondition(v.String(), true)
+		if err != nil || got != v || !got.Valid() {
+			t.Errorf("ParseWinCondition(%q) = %q, %v", v, got, err)
+		}
+	}
+	for _, v := range classes {
+		got, err := ParseClass(v.String(), true)
+		if err != nil || got != v || !got.Valid() {
+			t.Errorf("ParseClass(%q) = %q, %v", v, got, err)
+		}
+	}
+}
+
+func TestEnumUnknown(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name  string
+		parse func(s string, strict bool) (string, bool, error)
+	}{
+		{"team", func(s string, strict bool) (string, bool, error) {
+			v, err := ParseTeam(s, strict)
+			return string(v), v.Valid(), err
+		}},
+		{"buff", func(s string, strict bool) (string, bool, error) {
+			v, err := ParseBuff(s, strict)
+			return string(v), v.Valid(), err
+		}},
+		{"map", func(s string, strict bool) (string, bool, error) {
+			v, err := ParseMap(s, strict)
+			return string(v), v.Valid(), err
+		}},
+		{"orientation", func(s string, strict bool) (string, bool, error) {
+			v, err := ParseCabOrientation(s, strict)
+			return string(v), v.Valid(), err
+		}},
+		{"win condition", func(s string, strict bool) (string, bool, error) {
+			v, err := ParseWinCondition(s, strict)
+			return string(v), v.Valid(), err
+		}},
+		{"class", func(s string, strict bool) (string, bool, error) {
+			v, err := ParseClass(s, strict)
+			return string(v), v.Valid(), err
+		}},
+	}
+	for _, tc := range cases {
+		for _, s := range []string{"", "bogus"} {
+			_, _, err := tc.parse(s, true)
+			if err == nil {
+				t.Errorf("%s: strict parse of %q should fail", tc.name, s)
+			}
+			got, valid, err := tc.parse(s, false)
+			if err != nil {
+				t.Errorf("%s: lenient parse of %q failed: %s", tc.name, s, err)
+			}
+			if got != s || valid {
+				t.Errorf("%s: lenient parse of %q got %q, valid %t", tc.name, s, got, valid)
+			}
+		}
+	}
+}
+
+func TestEnumCase(t *testing.T) {
+	t.Parallel()
+	if v, err := ParseTeam("blue", true); err != nil || v != Blue {
+		t.Errorf("ParseTeam(blue) = %q, %v", v, err)
+	}
+	if v, err := ParseMap("MAP_DUSK", true); err != nil || v != Dusk {
+		t.Errorf("ParseMap(MAP_DUSK) = %q, %v", v, err)
+	}
+	if v, err := ParseMap("Night", true); err != nil || v != Night {
+		t.Errorf("ParseMap(Night) = %q, %v", v, err)
+	}
+	if v, err := ParseMap("bonus_snail", true); err != nil || v != BonusSnail {
+		t.Errorf("ParseMap(bonus_snail) = %q, %v", v, err)
+	}
+	if v, err := ParseCabOrientation("True", true); err != nil || v != GoldOnLeft {
+		t.Errorf("ParseCabOrientation(True) = %q, %v", v, err)
+	}
+	if v, err := ParseCabOrientation("False", true); err != nil || v != BlueOnLeft {
+		t.Errorf("ParseCabOrientation(False) = %q, %v", v, err)
+	}
+}
+
+func TestMapBonus(t *testing.T) {
+	t.Parallel()
+	for _, m := range []Map{Day, Night, Dusk, Twilight} {
+		if m.Bonus() {
+			t.Errorf("%s shouldn't be a bonus map", m)
+		}
+	}
+	for _, m := range []Map{BonusMilitary, BonusSnail} {
+		if !m.Bonus() {
+			t.Errorf("%s should be a bonus map", m)
+		}
+	}
+	ev, err := Parse("![k[gamestart],v[map_bonus_snail,True,0,False]]!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gs := ev.(GameStart); gs.Map != BonusSnail || gs.Orientation != GoldOnLeft {
+		t.Errorf("got %#v", gs)
+	}
+}
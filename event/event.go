@@ -60,9 +60,10 @@ type Team string
 
 const (
 	Gold Team = "Gold"
-	Blue      = "Blue"
-	// Red team is used in the game's demo, and isn't seen in real games.
-	Red = "Red"
+	Blue Team = "Blue"
+	// Red isn't a team people play on.  The cabinet sends it for gold's gates in blessMaiden events, and for the
+	// winner of some of the game's demos.
+	Red Team = "Red"
 )
 
 // Buff is a worker getting speed or becoming a warrior.
@@ -78,11 +79,16 @@ const (
 // Map is a game map or level.
 type Map string
 
-// TODO should bonus maps be listed here?
 const (
 	Day   Map = "map_day"
-	Night     = "map_night"
-	Dusk      = "map_dusk"
+	Night Map = "map_night"
+	Dusk  Map = "map_dusk"
+	// Twilight is the fourth main map, added in later cabinet software.
+	Twilight Map = "map_twilight"
+	// BonusMilitary is the bonus map only won by killing queens.
+	BonusMilitary Map = "map_bonus_military"
+	// BonusSnail is the bonus map only won by the snail.
+	BonusSnail Map = "map_bonus_snail"
 )
 
 // CabOrientation indicates which side of each other gold & blue cab are positioned.
@@ -90,20 +96,30 @@ type CabOrientation string
 
 const (
 	BlueOnLeft CabOrientation = "BLUE_ON_LEFT"
-	GoldOnLeft                = "GOLD_ON_LEFT"
+	GoldOnLeft CabOrientation = "GOLD_ON_LEFT"
 )
 
 // NewVictory creates a Victory type from victory event text.
 func NewVictory(v string) Victory {
+	ev, err := parseVictory(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseVictory parses victory event text.
+func parseVictory(v string) (Victory, error) {
 	vals := strings.Split(v, ",")
 	if len(vals) < 2 {
-		log.Printf("value should have at least 2 values: %s", v)
-		return Victory{}
+		return Victory{}, fmt.Errorf("value should have at least 2 values: %s", v)
 	}
+	t, _ := ParseTeam(vals[0], false)
+	w, _ := ParseWinCondition(vals[1], false)
 	return Victory{
-		Team: Team(vals[0]),
-		Type: WinCondition(vals[1]),
-	}
+		Team: t,
+		Type: w,
+	}, nil
 }
 
 // Victory is info on the winning team.
@@ -119,8 +135,8 @@ type WinCondition string
 
 const (
 	Military WinCondition = "military"
-	Economic              = "economic"
-	Snail                 = "snail"
+	Economic WinCondition = "economic"
+	Snail    WinCondition = "snail"
 )
 
 // event: kqstat.Pair{Key:"playerKill", Value:"638,519,1,6,Worker"}
@@ -134,9 +150,9 @@ const (
 	// Worker is what we refer to as drone.
 	Worker Class = "Worker"
 	// Soldier is what we refer to as warrior.
-	Soldier = "Soldier"
+	Soldier Class = "Soldier"
 	// Queen is the queen.
-	Queen = "Queen"
+	Queen Class = "Queen"
 )
 
 // ![k[alive],v[10:26:03 PM]]!
@@ -155,39 +171,43 @@ func NewAlive(v string) Alive {
 
 // NewPlayerKill creates a PlayerKill event from playerkill event text.
 func NewPlayerKill(v string) PlayerKill {
+	ev, err := parsePlayerKill(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parsePlayerKill parses playerkill event text.
+func parsePlayerKill(v string) (PlayerKill, error) {
 	vals := strings.Split(v, ",")
 	if len(vals) < 5 {
-		log.Printf("value should have at least 5 values: %s", v)
-		return PlayerKill{}
+		return PlayerKill{}, fmt.Errorf("value should have at least 5 values: %s", v)
 	}
 	x, err := strconv.Atoi(vals[0])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[0], err)
-		return PlayerKill{}
+		return PlayerKill{}, fmt.Errorf("failed Atoi for %s: %s", vals[0], err)
 	}
 	y, err := strconv.Atoi(vals[1])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[1], err)
-		return PlayerKill{}
+		return PlayerKill{}, fmt.Errorf("failed Atoi for %s: %s", vals[1], err)
 	}
 	slayer, err := strconv.Atoi(vals[2])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[2], err)
-		return PlayerKill{}
+		return PlayerKill{}, fmt.Errorf("failed Atoi for %s: %s", vals[2], err)
 	}
 	slain, err := strconv.Atoi(vals[3])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[3], err)
-		return PlayerKill{}
+		return PlayerKill{}, fmt.Errorf("failed Atoi for %s: %s", vals[3], err)
 	}
-	class := vals[4]
+	class, _ := ParseClass(vals[4], false)
 	return PlayerKill{
 		X:          x,
 		Y:          y,
 		Slayer:     Bee(slayer),
 		Slain:      Bee(slain),
-		SlainClass: Class(class),
-	}
+		SlainClass: class,
+	}, nil
 }
 
 // PlayerKill is a playerkill event.
@@ -204,72 +224,83 @@ type PlayerKill struct {
 
 // NewBlessMaiden creates a BlessMaiden event from blessMaiden event text.
 func NewBlessMaiden(v string) BlessMaiden {
+	ev, err := parseBlessMaiden(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseBlessMaiden parses blessMaiden event text.
+func parseBlessMaiden(v string) (BlessMaiden, error) {
 	vals := strings.Split(v, ",")
 	if len(vals) < 3 {
-		log.Printf("value should have at least 3 values: %s", v)
-		return BlessMaiden{}
+		return BlessMaiden{}, fmt.Errorf("value should have at least 3 values: %s", v)
 	}
 	x, err := strconv.Atoi(vals[0])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[0], err)
-		return BlessMaiden{}
+		return BlessMaiden{}, fmt.Errorf("failed Atoi for %s: %s", vals[0], err)
 	}
 	y, err := strconv.Atoi(vals[1])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[1], err)
-		return BlessMaiden{}
-	}
-	var t Team
-	switch strings.ToLower(vals[2]) {
-	case "blue":
-		t = Blue
-	case "gold":
-		t = Gold
-	case "red":
-		t = Red
+		return BlessMaiden{}, fmt.Errorf("failed Atoi for %s: %s", vals[1], err)
 	}
+	t, _ := ParseTeam(vals[2], false)
 	return BlessMaiden{
 		X:    x,
 		Y:    y,
 		Team: t,
-	}
+	}, nil
 }
 
 // BlessMaiden represents a queen tagging a gate.
 type BlessMaiden struct {
 	// X & Y are coordinates where the event occurred.
 	X, Y int
-	// Team is blue or gold team.
+	// Team is the team as sent by the cabinet, which is Red for gold's gates.  Use Owner for the team in control.
 	Team Team
 }
 
+// Owner returns the team now in control of the gate, which is Gold when the cabinet sent Red.
+func (b BlessMaiden) Owner() Team {
+	if b.Team == Red {
+		return Gold
+	}
+	return b.Team
+}
+
 // NewReserveMaiden creates a ReserveMaiden type from reserveMaiden event text.
 func NewReserveMaiden(v string) ReserveMaiden {
+	ev, err := parseReserveMaiden(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseReserveMaiden parses reserveMaiden event text.
+func parseReserveMaiden(v string) (ReserveMaiden, error) {
 	vals := strings.Split(v, ",")
 	if len(vals) < 3 {
-		log.Printf("value should have at least 3 values: %s", v)
-		return ReserveMaiden{}
+		return ReserveMaiden{}, fmt.Errorf("value should have at least 3 values: %s", v)
 	}
 	x, err := strconv.Atoi(vals[0])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[0], err)
-		return ReserveMaiden{}
+		return ReserveMaiden{}, fmt.Errorf("failed Atoi for %s: %s", vals[0], err)
 	}
 	y, err := strconv.Atoi(vals[1])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[1], err)
-		return ReserveMaiden{}
+		return ReserveMaiden{}, fmt.Errorf("failed Atoi for %s: %s", vals[1], err)
 	}
 	w, err := strconv.Atoi(vals[2])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[2], err)
-		return ReserveMaiden{}
+		return ReserveMaiden{}, fmt.Errorf("failed Atoi for %s: %s", vals[2], err)
 	}
 	return ReserveMaiden{
 		X:   x,
 		Y:   y,
 		Who: Bee(w),
-	}
+	}, nil
 }
 
 // ReserveMaiden represents a worker using a gate to obtain a Buff.
@@ -282,32 +313,37 @@ type ReserveMaiden struct {
 
 // NewUnreserveMaiden create a UnreserveMaiden type from unreserveMaiden event text.
 func NewUnreserveMaiden(v string) UnreserveMaiden {
+	ev, err := parseUnreserveMaiden(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseUnreserveMaiden parses unreserveMaiden event text.
+func parseUnreserveMaiden(v string) (UnreserveMaiden, error) {
 	vals := strings.Split(v, ",")
 	if len(vals) < 4 {
-		log.Printf("value should have at least 4 values: %s", v)
-		return UnreserveMaiden{}
+		return UnreserveMaiden{}, fmt.Errorf("value should have at least 4 values: %s", v)
 	}
 	x, err := strconv.Atoi(vals[0])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[0], err)
-		return UnreserveMaiden{}
+		return UnreserveMaiden{}, fmt.Errorf("failed Atoi for %s: %s", vals[0], err)
 	}
 	y, err := strconv.Atoi(vals[1])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[1], err)
-		return UnreserveMaiden{}
+		return UnreserveMaiden{}, fmt.Errorf("failed Atoi for %s: %s", vals[1], err)
 	}
 	// vals[2] seems to always be empty.
 	w, err := strconv.Atoi(vals[3])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[3], err)
-		return UnreserveMaiden{}
+		return UnreserveMaiden{}, fmt.Errorf("failed Atoi for %s: %s", vals[3], err)
 	}
 	return UnreserveMaiden{
 		X:   x,
 		Y:   y,
 		Who: Bee(w),
-	}
+	}, nil
 }
 
 // UnreserveMaiden represents a worker exiting a gate before the Buff is received.
@@ -320,33 +356,39 @@ type UnreserveMaiden struct {
 
 // NewUseMaiden creates a UseMaiden event from useMaiden event text.
 func NewUseMaiden(v string) UseMaiden {
+	ev, err := parseUseMaiden(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseUseMaiden parses useMaiden event text.
+func parseUseMaiden(v string) (UseMaiden, error) {
 	um := UseMaiden{}
 	vals := strings.Split(v, ",")
 	if len(vals) < 4 {
-		log.Printf("value should have at least 4 values: %s", v)
-		return um
+		return um, fmt.Errorf("value should have at least 4 values: %s", v)
 	}
 	x, err := strconv.Atoi(vals[0])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[0], err)
-		return um
+		return um, fmt.Errorf("failed Atoi for %s: %s", vals[0], err)
 	}
 	y, err := strconv.Atoi(vals[1])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[1], err)
-		return um
+		return um, fmt.Errorf("failed Atoi for %s: %s", vals[1], err)
 	}
 	w, err := strconv.Atoi(vals[3])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[3], err)
-		return um
+		return um, fmt.Errorf("failed Atoi for %s: %s", vals[3], err)
 	}
+	buff, _ := ParseBuff(vals[2], false)
 	return UseMaiden{
 		X:    x,
 		Y:    y,
-		Buff: Buff(vals[2]),
+		Buff: buff,
 		Who:  Bee(w),
-	}
+	}, nil
 }
 
 // UseMaiden represents a worker using a gate to obtain a Buff.
@@ -362,12 +404,20 @@ type UseMaiden struct {
 // NewPlayerNames creates a PlayerNames event from playernames event text.
 // This is a placeholder for the rfid stuff.
 func NewPlayerNames(v string) PlayerNames {
+	ev, err := parsePlayerNames(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parsePlayerNames parses playernames event text.
+func parsePlayerNames(v string) (PlayerNames, error) {
 	vals := strings.Split(v, ",")
 	if len(vals) != 10 {
-		log.Printf("value should have 10 values: %s", v)
-		return nil
+		return nil, fmt.Errorf("value should have 10 values: %s", v)
 	}
-	return PlayerNames(vals)
+	return PlayerNames(vals), nil
 }
 
 // PlayerNames is a list of players in order of their positions on the cabs.
@@ -375,25 +425,31 @@ type PlayerNames []string
 
 // NewGlance creates a Glance event from glance event text.
 func NewGlance(v string) Glance {
+	ev, err := parseGlance(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseGlance parses glance event text.
+func parseGlance(v string) (Glance, error) {
 	vals := strings.Split(v, ",")
 	if len(vals) < 2 {
-		log.Printf("value should have at least 2 values: %s", v)
-		return Glance{}
+		return Glance{}, fmt.Errorf("value should have at least 2 values: %s", v)
 	}
 	att, err := strconv.Atoi(vals[0])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[0], err)
-		return Glance{}
+		return Glance{}, fmt.Errorf("failed Atoi for %s: %s", vals[0], err)
 	}
 	tar, err := strconv.Atoi(vals[1])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[1], err)
-		return Glance{}
+		return Glance{}, fmt.Errorf("failed Atoi for %s: %s", vals[1], err)
 	}
 	return Glance{
 		Attacker: Bee(att),
 		Target:   Bee(tar),
-	}
+	}, nil
 }
 
 // Glance is an event representing when an attacker bounces off their target instead of delivering a killing blow.
@@ -406,11 +462,20 @@ type Glance struct {
 
 // NewCarryFood creates a CarryFood event from carryFood event text.
 func NewCarryFood(v string) CarryFood {
+	ev, err := parseCarryFood(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseCarryFood parses carryFood event text.
+func parseCarryFood(v string) (CarryFood, error) {
 	w, err := strconv.Atoi(v)
 	if err != nil {
-		panic(err)
+		return CarryFood{}, fmt.Errorf("failed Atoi for %s: %s", v, err)
 	}
-	return CarryFood{Who: Bee(w)}
+	return CarryFood{Who: Bee(w)}, nil
 }
 
 // CarryFood is an event representing a worker picking up a berry.
@@ -420,17 +485,37 @@ type CarryFood struct {
 }
 
 // NewGameStart creates a GameStart event from gamestart event text.
+// The values are the map, whether gold is on the left, the game clock in seconds, and whether it's the attract mode demo.
 func NewGameStart(v string) GameStart {
+	ev, err := parseGameStart(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseGameStart parses gamestart event text.
+func parseGameStart(v string) (GameStart, error) {
 	vals := strings.Split(v, ",")
 	if len(vals) < 4 {
-		log.Printf("value should have at least 4 values: %s", v)
-		return GameStart{}
+		return GameStart{}, fmt.Errorf("value should have at least 4 values: %s", v)
 	}
+	m, _ := ParseMap(vals[0], false)
 	or := parseOrientation(vals[1])
+	t, err := parseSeconds(vals[2])
+	if err != nil {
+		return GameStart{}, err
+	}
+	attract, err := parseFlag(vals[3])
+	if err != nil {
+		return GameStart{}, err
+	}
 	return GameStart{
-		Map:         Map(vals[0]),
+		Map:         m,
 		Orientation: or,
-	}
+		Time:        t,
+		Attract:     attract,
+	}, nil
 }
 
 // GameStart is an event indicating a new game is beginning.
@@ -439,25 +524,49 @@ type GameStart struct {
 	Map Map
 	// Orientation is how the cabs are positioned next to each other.
 	Orientation CabOrientation
+	// Time is the game clock when the game started, which has always been 0 in practice.
+	Time time.Duration
+	// Attract is set for games played by the AI in the cabinet's attract mode demo, rather than by people.
+	Attract bool
+}
+
+// Bonus reports if the game is played on a bonus map.  The cabinet doesn't send a separate flag for bonus games.
+func (g GameStart) Bonus() bool {
+	return g.Map.Bonus()
 }
 
 // NewGameEnd creates a GameEnd event from gameend event text.
+// The values are the map, whether gold is on the left, the game's duration in seconds, and whether it's the attract mode demo.
 func NewGameEnd(v string) GameEnd {
+	ev, err := parseGameEnd(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseGameEnd parses gameend event text.
+func parseGameEnd(v string) (GameEnd, error) {
 	vals := strings.Split(v, ",")
 	if len(vals) < 4 {
-		log.Printf("value should have at least 4 values: %s", v)
-		return GameEnd{}
+		return GameEnd{}, fmt.Errorf("value should have at least 4 values: %s", v)
+	}
+	m, _ := ParseMap(vals[0], false)
+	or := parseOrientation(vals[1])
+	d, err := parseSeconds(vals[2])
+	if err != nil {
+		return GameEnd{}, err
 	}
-	dur, err := time.ParseDuration(vals[2] + "s")
+	attract, err := parseFlag(vals[3])
 	if err != nil {
-		log.Printf("failed ParseDuration on %s: %s", vals[2], err)
+		return GameEnd{}, err
 	}
-	or := parseOrientation(vals[1])
 	return GameEnd{
-		Map:         Map(vals[0]),
+		Map:         m,
 		Orientation: or,
-		Duration:    dur,
-	}
+		Duration:    d,
+		Attract:     attract,
+	}, nil
 }
 
 // GameEnd is an event representing the end of a game.
@@ -468,29 +577,42 @@ type GameEnd struct {
 	Orientation CabOrientation
 	// Duration is how long the game lasted.
 	Duration time.Duration
+	// Attract is set for games played by the AI in the cabinet's attract mode demo, rather than by people.
+	Attract bool
+}
+
+// Bonus reports if the game was played on a bonus map.
+func (g GameEnd) Bonus() bool {
+	return g.Map.Bonus()
 }
 
 // NewSpawn creates a Spawn event from spawn event text.
 func NewSpawn(v string) Spawn {
+	ev, err := parseSpawn(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseSpawn parses spawn event text.
+func parseSpawn(v string) (Spawn, error) {
 	vals := strings.Split(v, ",")
 	if len(vals) < 2 {
-		log.Printf("value should have at least 2 values: %s", v)
-		return Spawn{}
+		return Spawn{}, fmt.Errorf("value should have at least 2 values: %s", v)
 	}
 	w, err := strconv.Atoi(vals[0])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[0], err)
-		return Spawn{}
+		return Spawn{}, fmt.Errorf("failed Atoi for %s: %s", vals[0], err)
 	}
 	ai, err := strconv.ParseBool(vals[1])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[1], err)
-		return Spawn{}
+		return Spawn{}, fmt.Errorf("failed Atoi for %s: %s", vals[1], err)
 	}
 	return Spawn{
 		Who:  Bee(w),
 		IsAI: ai,
-	}
+	}, nil
 }
 
 // Spawn is an event that represents a player spawning.
@@ -503,32 +625,37 @@ type Spawn struct {
 
 // NewGetOnSnail creates a GetOnSnail event from getOnSnail event text.
 func NewGetOnSnail(v string) GetOnSnail {
+	ev, err := parseGetOnSnail(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseGetOnSnail parses getOnSnail event text.
+func parseGetOnSnail(v string) (GetOnSnail, error) {
 	gos := GetOnSnail{}
 	vals := strings.Split(v, ",")
 	if len(vals) < 3 {
-		log.Printf("value should have at least 3 values: %s", v)
-		return gos
+		return gos, fmt.Errorf("value should have at least 3 values: %s", v)
 	}
 	x, err := strconv.Atoi(vals[0])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[0], err)
-		return gos
+		return gos, fmt.Errorf("failed Atoi for %s: %s", vals[0], err)
 	}
 	y, err := strconv.Atoi(vals[1])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[1], err)
-		return gos
+		return gos, fmt.Errorf("failed Atoi for %s: %s", vals[1], err)
 	}
 	w, err := strconv.Atoi(vals[2])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[2], err)
-		return gos
+		return gos, fmt.Errorf("failed Atoi for %s: %s", vals[2], err)
 	}
 	return GetOnSnail{
 		X:   x,
 		Y:   y,
 		Who: Bee(w),
-	}
+	}, nil
 }
 
 // GetOnSnail is an event representing a worker beginning to ride on the snail.
@@ -541,32 +668,37 @@ type GetOnSnail struct {
 
 // NewGetOffSnail creates a GetOffSnail event from getOffSnail event text.
 func NewGetOffSnail(v string) GetOffSnail {
+	ev, err := parseGetOffSnail(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseGetOffSnail parses getOffSnail event text.
+func parseGetOffSnail(v string) (GetOffSnail, error) {
 	gos := GetOffSnail{}
 	vals := strings.Split(v, ",")
 	if len(vals) < 4 {
-		log.Printf("value should have at least 4 values: %s", v)
-		return gos
+		return gos, fmt.Errorf("value should have at least 4 values: %s", v)
 	}
 	x, err := strconv.Atoi(vals[0])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[0], err)
-		return gos
+		return gos, fmt.Errorf("failed Atoi for %s: %s", vals[0], err)
 	}
 	y, err := strconv.Atoi(vals[1])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[1], err)
-		return gos
+		return gos, fmt.Errorf("failed Atoi for %s: %s", vals[1], err)
 	}
 	w, err := strconv.Atoi(vals[3])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[3], err)
-		return gos
+		return gos, fmt.Errorf("failed Atoi for %s: %s", vals[3], err)
 	}
 	return GetOffSnail{
 		X:   x,
 		Y:   y,
 		Who: Bee(w),
-	}
+	}, nil
 }
 
 // GetOffSnail is an event representing a worker ending a ride on the snail.
@@ -579,38 +711,42 @@ type GetOffSnail struct {
 
 // NewSnailEat creates a SnailEat event from snailEat event text.
 func NewSnailEat(v string) SnailEat {
+	ev, err := parseSnailEat(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseSnailEat parses snailEat event text.
+func parseSnailEat(v string) (SnailEat, error) {
 	gos := SnailEat{}
 	vals := strings.Split(v, ",")
 	if len(vals) < 4 {
-		log.Printf("value should have at least 4 values: %s", v)
-		return gos
+		return gos, fmt.Errorf("value should have at least 4 values: %s", v)
 	}
 	x, err := strconv.Atoi(vals[0])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[0], err)
-		return gos
+		return gos, fmt.Errorf("failed Atoi for %s: %s", vals[0], err)
 	}
 	y, err := strconv.Atoi(vals[1])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[1], err)
-		return gos
+		return gos, fmt.Errorf("failed Atoi for %s: %s", vals[1], err)
 	}
 	r, err := strconv.Atoi(vals[2])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[2], err)
-		return gos
+		return gos, fmt.Errorf("failed Atoi for %s: %s", vals[2], err)
 	}
 	m, err := strconv.Atoi(vals[3])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[3], err)
-		return gos
+		return gos, fmt.Errorf("failed Atoi for %s: %s", vals[3], err)
 	}
 	return SnailEat{
 		X:     x,
 		Y:     y,
 		Rider: Bee(r),
 		Meal:  Bee(m),
-	}
+	}, nil
 }
 
 // SnailEat is an event representing the snail beginning to eat a worker.
@@ -625,32 +761,37 @@ type SnailEat struct {
 
 // NewSnailEscape creates a SnailEscape event from snailEscape event text.
 func NewSnailEscape(v string) SnailEscape {
+	ev, err := parseSnailEscape(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseSnailEscape parses snailEscape event text.
+func parseSnailEscape(v string) (SnailEscape, error) {
 	se := SnailEscape{}
 	vals := strings.Split(v, ",")
 	if len(vals) < 3 {
-		log.Printf("value should have at least 3 values: %s", v)
-		return se
+		return se, fmt.Errorf("value should have at least 3 values: %s", v)
 	}
 	x, err := strconv.Atoi(vals[0])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[0], err)
-		return se
+		return se, fmt.Errorf("failed Atoi for %s: %s", vals[0], err)
 	}
 	y, err := strconv.Atoi(vals[1])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[1], err)
-		return se
+		return se, fmt.Errorf("failed Atoi for %s: %s", vals[1], err)
 	}
 	w, err := strconv.Atoi(vals[2])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[2], err)
-		return se
+		return se, fmt.Errorf("failed Atoi for %s: %s", vals[2], err)
 	}
 	return SnailEscape{
 		X:   x,
 		Y:   y,
 		Who: Bee(w),
-	}
+	}, nil
 }
 
 type SnailEscape struct {
@@ -662,31 +803,36 @@ type SnailEscape struct {
 
 // NewBerryDeposit creates a BerryDeposit event from berryDeposit event text.
 func NewBerryDeposit(v string) BerryDeposit {
+	ev, err := parseBerryDeposit(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseBerryDeposit parses berryDeposit event text.
+func parseBerryDeposit(v string) (BerryDeposit, error) {
 	vals := strings.Split(v, ",")
 	if len(vals) < 3 {
-		log.Printf("value should have at least 3 values: %s", v)
-		return BerryDeposit{}
+		return BerryDeposit{}, fmt.Errorf("value should have at least 3 values: %s", v)
 	}
 	x, err := strconv.Atoi(vals[0])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[0], err)
-		return BerryDeposit{}
+		return BerryDeposit{}, fmt.Errorf("failed Atoi for %s: %s", vals[0], err)
 	}
 	y, err := strconv.Atoi(vals[1])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[1], err)
-		return BerryDeposit{}
+		return BerryDeposit{}, fmt.Errorf("failed Atoi for %s: %s", vals[1], err)
 	}
 	w, err := strconv.Atoi(vals[2])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[2], err)
-		return BerryDeposit{}
+		return BerryDeposit{}, fmt.Errorf("failed Atoi for %s: %s", vals[2], err)
 	}
 	return BerryDeposit{
 		X:   x,
 		Y:   y,
 		Who: Bee(w),
-	}
+	}, nil
 }
 
 // BerryDeposit is an event representing a worker putting a berry in their hive.
@@ -699,32 +845,37 @@ type BerryDeposit struct {
 
 // NewBerryKickIn creates a BerryKickIn event from berryKickIn event text.
 func NewBerryKickIn(v string) BerryKickIn {
+	ev, err := parseBerryKickIn(v)
+	if err != nil {
+		log.Print(err)
+	}
+	return ev
+}
+
+// parseBerryKickIn parses berryKickIn event text.
+func parseBerryKickIn(v string) (BerryKickIn, error) {
 	bki := BerryKickIn{}
 	vals := strings.Split(v, ",")
 	if len(vals) < 3 {
-		log.Printf("value should have at least 3 values: %s", v)
-		return bki
+		return bki, fmt.Errorf("value should have at least 3 values: %s", v)
 	}
 	x, err := strconv.Atoi(vals[0])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[0], err)
-		return bki
+		return bki, fmt.Errorf("failed Atoi for %s: %s", vals[0], err)
 	}
 	y, err := strconv.Atoi(vals[1])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[1], err)
-		return bki
+		return bki, fmt.Errorf("failed Atoi for %s: %s", vals[1], err)
 	}
 	w, err := strconv.Atoi(vals[2])
 	if err != nil {
-		log.Printf("failed Atoi for %s: %s", vals[2], err)
-		return bki
+		return bki, fmt.Errorf("failed Atoi for %s: %s", vals[2], err)
 	}
 	return BerryKickIn{
 		X:   x,
 		Y:   y,
 		Who: Bee(w),
-	}
+	}, nil
 }
 
 type BerryKickIn struct {
@@ -735,52 +886,134 @@ type BerryKickIn struct {
 }
 
 // Parse parses a line of event text from the stats service, and returns it as an event.
+// It returns an error for unknown keys, and for values that are missing or aren't numbers where the event needs them.
 func Parse(line string) (Event, error) {
 	p, err := parseKV(line)
 	if err != nil {
 		return nil, err
 	}
+	var ev Event
 	switch p.Key {
 	case "alive":
-		return NewAlive(p.Value), nil
+		ev = NewAlive(p.Value)
 	case "berryDeposit":
-		return NewBerryDeposit(p.Value), nil
+		ev, err = parseBerryDeposit(p.Value)
 	case "berryKickIn":
-		return NewBerryKickIn(p.Value), nil
+		ev, err = parseBerryKickIn(p.Value)
 	case "blessMaiden":
-		return NewBlessMaiden(p.Value), nil
+		ev, err = parseBlessMaiden(p.Value)
 	case "carryFood":
-		return NewCarryFood(p.Value), nil
+		ev, err = parseCarryFood(p.Value)
 	case "gameend":
-		return NewGameEnd(p.Value), nil
+		ev, err = parseGameEnd(p.Value)
 	case "gamestart":
-		return NewGameStart(p.Value), nil
+		ev, err = parseGameStart(p.Value)
 	case "getOffSnail: ":
-		return NewGetOffSnail(p.Value), nil
+		ev, err = parseGetOffSnail(p.Value)
 	case "getOnSnail: ":
-		return NewGetOnSnail(p.Value), nil
+		ev, err = parseGetOnSnail(p.Value)
 	case "glance":
-		return NewGlance(p.Value), nil
+		ev, err = parseGlance(p.Value)
 	case "playerKill":
-		return NewPlayerKill(p.Value), nil
+		ev, err = parsePlayerKill(p.Value)
 	case "playernames":
-		return NewPlayerNames(p.Value), nil
+		ev, err = parsePlayerNames(p.Value)
 	case "reserveMaiden":
-		return NewReserveMaiden(p.Value), nil
+		ev, err = parseReserveMaiden(p.Value)
 	case "snailEat":
-		return NewSnailEat(p.Value), nil
+		ev, err = parseSnailEat(p.Value)
 	case "snailEscape":
-		return NewSnailEscape(p.Value), nil
+		ev, err = parseSnailEscape(p.Value)
 	case "spawn":
-		return NewSpawn(p.Value), nil
+		ev, err = parseSpawn(p.Value)
 	case "unreserveMaiden":
-		return NewUnreserveMaiden(p.Value), nil
+		ev, err = parseUnreserveMaiden(p.Value)
 	case "useMaiden":
-		return NewUseMaiden(p.Value), nil
+		ev, err = parseUseMaiden(p.Value)
 	case "victory":
-		return NewVictory(p.Value), nil
+		ev, err = parseVictory(p.Value)
+	default:
+		return nil, fmt.Errorf("unknown event: %v", p)
 	}
-	return nil, fmt.Errorf("unknown event: %v", p)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", strings.TrimSuffix(p.Key, ": "), err)
+	}
+	return ev, nil
+}
+
+// Key returns the key used by the stats service for the type of ev, or an empty string for unknown events.
+// Note the snail mount and dismount keys end with ": " as sent by the stats service.
+func Key(ev Event) string {
+	switch ev.(type) {
+	case Alive:
+		return "alive"
+	case BerryDeposit:
+		return "berryDeposit"
+	case BerryKickIn:
+		return "berryKickIn"
+	case BlessMaiden:
+		return "blessMaiden"
+	case CarryFood:
+		return "carryFood"
+	case GameEnd:
+		return "gameend"
+	case GameStart:
+		return "gamestart"
+	case GetOffSnail:
+		return "getOffSnail: "
+	case GetOnSnail:
+		return "getOnSnail: "
+	case Glance:
+		return "glance"
+	case PlayerKill:
+		return "playerKill"
+	case PlayerNames:
+		return "playernames"
+	case ReserveMaiden:
+		return "reserveMaiden"
+	case SnailEat:
+		return "snailEat"
+	case SnailEscape:
+		return "snailEscape"
+	case Spawn:
+		return "spawn"
+	case UnreserveMaiden:
+		return "unreserveMaiden"
+	case UseMaiden:
+		return "useMaiden"
+	case Victory:
+		return "victory"
+	}
+	return ""
+}
+
+// Position returns the coordinates where ev occurred, and false for events without coordinates.
+func Position(ev Event) (x, y int, ok bool) {
+	switch v := ev.(type) {
+	case BerryDeposit:
+		return v.X, v.Y, true
+	case BerryKickIn:
+		return v.X, v.Y, true
+	case BlessMaiden:
+		return v.X, v.Y, true
+	case GetOffSnail:
+		return v.X, v.Y, true
+	case GetOnSnail:
+		return v.X, v.Y, true
+	case PlayerKill:
+		return v.X, v.Y, true
+	case ReserveMaiden:
+		return v.X, v.Y, true
+	case SnailEat:
+		return v.X, v.Y, true
+	case SnailEscape:
+		return v.X, v.Y, true
+	case UnreserveMaiden:
+		return v.X, v.Y, true
+	case UseMaiden:
+		return v.X, v.Y, true
+	}
+	return 0, 0, false
 }
 
 // pair is an event after parsing into a key and value.
@@ -803,13 +1036,29 @@ func parseKV(line string) (pair, error) {
 }
 
 // parseOrientation sets the CabOrientation based on a boolean value in the event text.
+// Unknown values result in an empty CabOrientation.
 func parseOrientation(v string) CabOrientation {
-	var or CabOrientation
-	switch v {
-	case "True":
-		or = GoldOnLeft
-	case "False":
-		or = BlueOnLeft
+	or, err := ParseCabOrientation(v, true)
+	if err != nil {
+		return ""
 	}
 	return or
 }
+
+// parseSeconds parses a number of seconds in the event text, such as a game's duration.
+func parseSeconds(v string) (time.Duration, error) {
+	d, err := time.ParseDuration(v + "s")
+	if err != nil {
+		return 0, fmt.Errorf("failed ParseDuration on %s: %s", v, err)
+	}
+	return d, nil
+}
+
+// parseFlag parses a boolean value in the event text, such as True or False.
+func parseFlag(v string) (bool, error) {
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("failed ParseBool on %s: %s", v, err)
+	}
+	return b, nil
+}
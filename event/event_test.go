@@ -1,9 +1,14 @@
 package event
 
 import (
+	"bufio"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/rickyninja/kqstat/internal/testutil"
 )
 
 func TestParseKV(t *testing.T) {
@@ -161,6 +166,75 @@ func TestParseEvent(t *testing.T) {
 	}
 }
 
+func TestParse_Malformed(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"![k[carryFood],v[x]]!", `carryFood: failed Atoi for x`},
+		{"![k[carryFood],v[3,4]]!", `carryFood: failed Atoi for 3,4`},
+		{"![k[playerKill],v[1301,1014,1]]!", "playerKill: value should have at least 5 values"},
+		{"![k[getOnSnail: ],v[950,,4]]!", "getOnSnail: failed Atoi for "},
+		{"![k[playernames],v[one,two]]!", "playernames: value should have 10 values"},
+		{"![k[spawn],v[3,maybe]]!", "spawn: failed Atoi for maybe"},
+		{"![k[gamestart],v[map_day,False,soon,False]]!", "gamestart: failed ParseDuration on soon"},
+		{"![k[gamestart],v[map_day,False,0,Maybe]]!", "gamestart: failed ParseBool on Maybe"},
+		{"![k[gameend],v[map_day,False,,False]]!", "gameend: failed ParseDuration on "},
+		{"![k[gameend],v[map_day,False,128.2457,]]!", "gameend: failed ParseBool on "},
+	}
+	for _, tc := range tests {
+		ev, err := Parse(tc.line)
+		if err == nil || !strings.HasPrefix(err.Error(), tc.want) {
+			t.Errorf("%s: got %v, %v want error %q", tc.line, ev, err, tc.want)
+		}
+		if ev != nil {
+			t.Errorf("%s: got event %#v with error", tc.line, ev)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	t.Parallel()
+	lines := []string{
+		"![k[alive],v[1:25:40 PM]]!",
+		"![k[berryDeposit],v[763,957,3]]!",
+		"![k[berryKickIn],v[831,684,4]]!",
+		"![k[blessMaiden],v[1220,260,Blue]]!",
+		"![k[carryFood],v[3]]!",
+		"![k[gameend],v[map_night,False,128.2457,False]]!",
+		"![k[gamestart],v[map_dusk,False,0,False]]!",
+		"![k[getOffSnail: ],v[950,11,,4]]!",
+		"![k[getOnSnail: ],v[950,11,4]]!",
+		"![k[glance],v[1,2]]!",
+		"![k[playerKill],v[1301,1014,1,10,Soldier]]!",
+		"![k[playernames],v[one,two,three,four,five,six,seven,eight,nine,ten]]!",
+		"![k[reserveMaiden],v[560,260,10]]!",
+		"![k[snailEat],v[976,11,3,4]]!",
+		"![k[snailEscape],v[317,11,4]]!",
+		"![k[spawn],v[3,False]]!",
+		"![k[unreserveMaiden],v[1220,260,,7]]!",
+		"![k[useMaiden],v[700,260,maiden_wings,6]]!",
+		"![k[victory],v[Gold,military]]!",
+	}
+	for _, line := range lines {
+		ev, err := Parse(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := parseKV(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := Key(ev); got != p.Key {
+			t.Errorf("wrong Key, got %s want %s", got, p.Key)
+		}
+	}
+	if got := Key(struct{}{}); got != "" {
+		t.Errorf("wrong Key for unknown event, got %s", got)
+	}
+}
+
 func TestNewVictory(t *testing.T) {
 	t.Parallel()
 	v := NewVictory("Blue,economic")
@@ -433,15 +507,24 @@ func TestNewGameStart(t *testing.T) {
 		{value: "map_dusk,False,0,False", want: GameStart{
 			Map: Dusk,
 		}},
+		{value: "map_bonus_snail,True,1.5,True", want: GameStart{
+			Map:         BonusSnail,
+			Orientation: GoldOnLeft,
+			Time:        1500 * time.Millisecond,
+			Attract:     true,
+		}},
 	}
 	for _, tc := range cases {
 		tc := tc
 		t.Run(tc.value, func(t *testing.T) {
 			t.Parallel()
 			want := tc.want
+			if want.Orientation == "" {
+				want.Orientation = BlueOnLeft
+			}
 			got := NewGameStart(tc.value)
-			if got.Map != want.Map {
-				t.Errorf("wrong Map, got %s want %s", got.Map, want.Map)
+			if got != want {
+				t.Errorf("got %#v want %#v", got, want)
 			}
 		})
 	}
@@ -465,23 +548,84 @@ func TestNewGameEnd(t *testing.T) {
 			Map:      Dusk,
 			Duration: time.Minute * 30,
 		}},
+		{value: "map_day,True,42,True", want: GameEnd{
+			Map:         Day,
+			Orientation: GoldOnLeft,
+			Duration:    42 * time.Second,
+			Attract:     true,
+		}},
 	}
 	for _, tc := range cases {
 		tc := tc
 		t.Run(tc.value, func(t *testing.T) {
 			t.Parallel()
 			want := tc.want
-			got := NewGameEnd(tc.value)
-			if got.Map != want.Map {
-				t.Errorf("wrong Map, got %s want %s", got.Map, want.Map)
+			if want.Orientation == "" {
+				want.Orientation = BlueOnLeft
 			}
-			if got.Duration != want.Duration {
-				t.Errorf("wrong Duration, got %s want %s", got.Duration, want.Duration)
+			got := NewGameEnd(tc.value)
+			if got != want {
+				t.Errorf("got %#v want %#v", got, want)
 			}
 		})
 	}
 }
 
+// TestGameFields checks every field of the gamestart and gameend events of each game in a recorded log.
+func TestGameFields(t *testing.T) {
+	t.Parallel()
+	fd, err := os.Open(testutil.BB3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	var (
+		starts, ends int
+		start        *GameStart
+	)
+	sc := bufio.NewScanner(fd)
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.Contains(line, "[gamestart]") && !strings.Contains(line, "[gameend]") {
+			continue
+		}
+		ev, err := Parse(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch v := ev.(type) {
+		case GameStart:
+			starts++
+			if !v.Map.Valid() || v.Bonus() || v.Orientation != BlueOnLeft || v.Time != 0 || v.Attract {
+				t.Errorf("game %d: unexpected gamestart %#v", starts, v)
+			}
+			start = &v
+		case GameEnd:
+			ends++
+			if !v.Map.Valid() || v.Bonus() || v.Orientation != BlueOnLeft || v.Duration <= 0 || v.Attract {
+				t.Errorf("gameend %d: unexpected gameend %#v", ends, v)
+			}
+			// The log begins during the first game, so its gameend has no gamestart.
+			if start == nil {
+				if ends != 1 {
+					t.Errorf("gameend %d has no gamestart", ends)
+				}
+				continue
+			}
+			if v.Map != start.Map || v.Orientation != start.Orientation {
+				t.Errorf("game %d started as %#v and ended as %#v", starts, *start, v)
+			}
+			start = nil
+		}
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if starts != 17 || ends != 18 {
+		t.Errorf("got %d gamestarts and %d gameends, want 17 and 18", starts, ends)
+	}
+}
+
 func TestNewSpawn(t *testing.T) {
 	t.Parallel()
 	got := NewSpawn("2,False")
@@ -586,3 +730,39 @@ func TestNewBerryKickIn(t *testing.T) {
 		t.Errorf("wrong Who, got %d want %d", got.Who, want.Who)
 	}
 }
+
+func TestPosition(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		line string
+		x, y int
+		ok   bool
+	}{
+		{"![k[berryDeposit],v[763,957,3]]!", 763, 957, true},
+		{"![k[blessMaiden],v[1220,260,Blue]]!", 1220, 260, true},
+		{"![k[getOffSnail: ],v[950,11,,4]]!", 950, 11, true},
+		{"![k[playerKill],v[1301,1014,1,10,Soldier]]!", 1301, 1014, true},
+		{"![k[snailEat],v[976,11,3,4]]!", 976, 11, true},
+		{"![k[useMaiden],v[700,260,maiden_wings,6]]!", 700, 260, true},
+		{"![k[carryFood],v[3]]!", 0, 0, false},
+		{"![k[victory],v[Gold,military]]!", 0, 0, false},
+	}
+	for _, tt := range tests {
+		ev, err := Parse(tt.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		x, y, ok := Position(ev)
+		if x != tt.x || y != tt.y || ok != tt.ok {
+			t.Errorf("%s: got %d,%d,%v want %d,%d,%v", tt.line, x, y, ok, tt.x, tt.y, tt.ok)
+		}
+	}
+}
+
+func TestBlessMaiden_Owner(t *testing.T) {
+	for team, want := range map[Team]Team{Red: Gold, Gold: Gold, Blue: Blue} {
+		if got := (BlessMaiden{Team: team}).Owner(); got != want {
+			t.Errorf("%s: got %s want %s", team, got, want)
+		}
+	}
+}
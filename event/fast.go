@@ -0,0 +1,492 @@
+package event
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+// Decoder parses event text into typed events that it reuses, so it doesn't allocate apart from holding the time of
+// its first alive event and each change of player names.  Over testdata/bb3 it takes about a quarter of the time of
+// Parse, 0.3ms against 1.2ms on a typical machine, without any allocations against Parse's 17,644.  Use it where
+// events are processed in bulk, such as when reprocessing old recordings.
+//
+// After each Decode, Key says which field holds the event.  The fields are overwritten by the next Decode, apart from
+// PlayerNames which is only replaced when the names change, so copy anything needed for longer.  Event returns the
+// same events as Parse.
+type Decoder struct {
+	// Key is the key of the last event decoded, as returned by Key, or an empty string if it couldn't be decoded.
+	Key string
+	// AliveTime is the time of the last alive event.
+	AliveTime       []byte
+	BerryDeposit    BerryDeposit
+	BerryKickIn     BerryKickIn
+	BlessMaiden     BlessMaiden
+	CarryFood       CarryFood
+	GameEnd         GameEnd
+	GameStart       GameStart
+	GetOffSnail     GetOffSnail
+	GetOnSnail      GetOnSnail
+	Glance          Glance
+	PlayerKill      PlayerKill
+	PlayerNames     PlayerNames
+	ReserveMaiden   ReserveMaiden
+	SnailEat        SnailEat
+	SnailEscape     SnailEscape
+	Spawn           Spawn
+	UnreserveMaiden UnreserveMaiden
+	UseMaiden       UseMaiden
+	Victory         Victory
+	// alive holds AliveTime, so line may be reused once Decode returns.
+	alive []byte
+}
+
+// Decode parses the event text in line.  Lines that Parse would treat specially, such as unknown values it passes
+// through or malformed values it rejects, are handed to Parse, and its error is returned.
+func (d *Decoder) Decode(line []byte) error {
+	if d.decode(line) {
+		if d.Key == "alive" {
+			d.alive = append(d.alive[:0], d.AliveTime...)
+			d.AliveTime = d.alive
+		}
+		return nil
+	}
+	ev, err := Parse(string(line))
+	d.set(ev)
+	return err
+}
+
+// Event returns the last event decoded, or nil if it couldn't be decoded.
+func (d *Decoder) Event() Event {
+	switch d.Key {
+	case "alive":
+		return Alive{Time: string(d.AliveTime)}
+	case "berryDeposit":
+		return d.BerryDeposit
+	case "berryKickIn":
+		return d.BerryKickIn
+	case "blessMaiden":
+		return d.BlessMaiden
+	case "carryFood":
+		return d.CarryFood
+	case "gameend":
+		return d.GameEnd
+	case "gamestart":
+		return d.GameStart
+	case "getOffSnail: ":
+		return d.GetOffSnail
+	case "getOnSnail: ":
+		return d.GetOnSnail
+	case "glance":
+		return d.Glance
+	case "playerKill":
+		return d.PlayerKill
+	case "playernames":
+		return d.PlayerNames
+	case "reserveMaiden":
+		return d.ReserveMaiden
+	case "snailEat":
+		return d.SnailEat
+	case "snailEscape":
+		return d.SnailEscape
+	case "spawn":
+		return d.Spawn
+	case "unreserveMaiden":
+		return d.UnreserveMaiden
+	case "useMaiden":
+		return d.UseMaiden
+	case "victory":
+		return d.Victory
+	}
+	return nil
+}
+
+// set stores ev as the last event decoded.
+func (d *Decoder) set(ev Event) {
+	d.Key = Key(ev)
+	switch v := ev.(type) {
+	case Alive:
+		d.alive = append(d.alive[:0], v.Time...)
+		d.AliveTime = d.alive
+	case BerryDeposit:
+		d.BerryDeposit = v
+	case BerryKickIn:
+		d.BerryKickIn = v
+	case BlessMaiden:
+		d.BlessMaiden = v
+	case CarryFood:
+		d.CarryFood = v
+	case GameEnd:
+		d.GameEnd = v
+	case GameStart:
+		d.GameStart = v
+	case GetOffSnail:
+		d.GetOffSnail = v
+	case GetOnSnail:
+		d.GetOnSnail = v
+	case Glance:
+		d.Glance = v
+	case PlayerKill:
+		d.PlayerKill = v
+	case PlayerNames:
+		d.PlayerNames = v
+	case ReserveMaiden:
+		d.ReserveMaiden = v
+	case SnailEat:
+		d.SnailEat = v
+	case SnailEscape:
+		d.SnailEscape = v
+	case Spawn:
+		d.Spawn = v
+	case UnreserveMaiden:
+		d.UnreserveMaiden = v
+	case UseMaiden:
+		d.UseMaiden = v
+	case Victory:
+		d.Victory = v
+	}
+}
+
+// ParseBytes is like Parse, but parses the event text in line without converting it to a string or splitting it, so
+// line may be reused once it returns.  It allocates only the returned Event and any text it holds, such as the time
+// of an Alive, which over testdata/bb3 makes it take about half the time of Parse.  A Decoder avoids even that.
+func ParseBytes(line []byte) (Event, error) {
+	var d Decoder
+	if d.decode(line) {
+		return d.Event(), nil
+	}
+	return Parse(string(line))
+}
+
+// The text around an event's key and value.  They're compared as strings, which compiles to a comparison of their
+// bytes in place.
+const (
+	keyPrefix   = "![k["
+	valueSep    = "],v["
+	valueSuffix = "]]!"
+)
+
+// decode parses events into d when they're well formed, and returns false for anything else.
+// AliveTime is left pointing into line.
+func (d *Decoder) decode(line []byte) bool {
+	d.Key = ""
+	if len(line) < len(keyPrefix) || string(line[:len(keyPrefix)]) != keyPrefix {
+		return false
+	}
+	// Keys never contain ], so the first one starts the separator.  Keys are short enough that finding it with a loop
+	// is quicker than calling bytes.IndexByte.
+	i := len(keyPrefix)
+	for i < len(line) && line[i] != ']' {
+		i++
+	}
+	if len(line)-i < len(valueSep) || string(line[i:i+len(valueSep)]) != valueSep {
+		return false
+	}
+	key, value := line[len(keyPrefix):i], line[i+len(valueSep):]
+	if n := len(value); n > 0 && value[n-1] == '\n' {
+		value = value[:n-1]
+	}
+	n := len(value) - len(valueSuffix)
+	if n < 0 || string(value[n:]) != valueSuffix {
+		return false
+	}
+	value = value[:n]
+
+	// A ] may be part of a second separator that Parse would ignore everything after, which isn't worth handling here.
+	// Values that are read as numbers or names can't contain one, so only text is checked.
+	v := values{b: value}
+	switch string(key) {
+	case "alive":
+		if bytes.IndexByte(value, ']') >= 0 {
+			return false
+		}
+		d.Key, d.AliveTime = "alive", value
+		return true
+	case "berryDeposit":
+		d.Key, d.BerryDeposit = "berryDeposit", BerryDeposit{X: v.int(), Y: v.int(), Who: Bee(v.int())}
+	case "berryKickIn":
+		d.Key, d.BerryKickIn = "berryKickIn", BerryKickIn{X: v.int(), Y: v.int(), Who: Bee(v.int())}
+	case "blessMaiden":
+		d.Key, d.BlessMaiden = "blessMaiden", BlessMaiden{X: v.int(), Y: v.int(), Team: v.team()}
+	case "carryFood":
+		d.Key, d.CarryFood = "carryFood", CarryFood{Who: Bee(v.int())}
+		// Parse requires the whole value to be a single number.
+		v.bad = v.bad || !v.end
+	case "gameend":
+		d.Key = "gameend"
+		d.GameEnd = GameEnd{Map: v.gameMap(), Orientation: v.orientation(), Duration: v.seconds(), Attract: v.flag()}
+	case "gamestart":
+		d.Key = "gamestart"
+		d.GameStart = GameStart{Map: v.gameMap(), Orientation: v.orientation(), Time: v.seconds(), Attract: v.flag()}
+	case "getOffSnail: ":
+		d.Key, d.GetOffSnail = "getOffSnail: ", GetOffSnail{X: v.int(), Y: v.int()}
+		v.skip()
+		d.GetOffSnail.Who = Bee(v.int())
+	case "getOnSnail: ":
+		d.Key, d.GetOnSnail = "getOnSnail: ", GetOnSnail{X: v.int(), Y: v.int(), Who: Bee(v.int())}
+	case "glance":
+		d.Key, d.Glance = "glance", Glance{Attacker: Bee(v.int()), Target: Bee(v.int())}
+	case "playerKill":
+		d.Key = "playerKill"
+		d.PlayerKill = PlayerKill{X: v.int(), Y: v.int(), Slayer: Bee(v.int()), Slain: Bee(v.int()), SlainClass: v.class()}
+	case "playernames":
+		if bytes.Count(value, []byte{','}) != 9 || bytes.IndexByte(value, ']') >= 0 {
+			return false
+		}
+		d.Key = "playernames"
+		if !d.PlayerNames.equal(value) {
+			d.PlayerNames = PlayerNames(strings.Split(string(value), ","))
+		}
+		return true
+	case "reserveMaiden":
+		d.Key, d.ReserveMaiden = "reserveMaiden", ReserveMaiden{X: v.int(), Y: v.int(), Who: Bee(v.int())}
+	case "snailEat":
+		d.Key, d.SnailEat = "snailEat", SnailEat{X: v.int(), Y: v.int(), Rider: Bee(v.int()), Meal: Bee(v.int())}
+	case "snailEscape":
+		d.Key, d.SnailEscape = "snailEscape", SnailEscape{X: v.int(), Y: v.int(), Who: Bee(v.int())}
+	case "spawn":
+		d.Key, d.Spawn = "spawn", Spawn{Who: Bee(v.int()), IsAI: v.flag()}
+	case "unreserveMaiden":
+		d.Key, d.UnreserveMaiden = "unreserveMaiden", UnreserveMaiden{X: v.int(), Y: v.int()}
+		v.skip()
+		d.UnreserveMaiden.Who = Bee(v.int())
+	case "useMaiden":
+		d.Key, d.UseMaiden = "useMaiden", UseMaiden{X: v.int(), Y: v.int(), Buff: v.buff(), Who: Bee(v.int())}
+	case "victory":
+		d.Key, d.Victory = "victory", Victory{Team: v.team(), Type: v.winCondition()}
+	default:
+		return false
+	}
+	if v.bad {
+		d.Key = ""
+		return false
+	}
+	return true
+}
+
+// equal reports if the names are the comma separated names in value.
+func (p PlayerNames) equal(value []byte) bool {
+	for i, name := range p {
+		if i > 0 {
+			if len(value) == 0 || value[0] != ',' {
+				return false
+			}
+			value = value[1:]
+		}
+		if len(value) < len(name) || string(value[:len(name)]) != name {
+			return false
+		}
+		value = value[len(name):]
+	}
+	return p != nil && len(value) == 0
+}
+
+// values reads the comma separated values of an event in order, parsing each as it's read rather than splitting them
+// first.  Anything after the values an event needs is ignored, as Parse does.
+type values struct {
+	b []byte
+	// end is set once the last value has been read.
+	end bool
+	// bad is set once a value was missing or not what the event needs.
+	bad bool
+}
+
+// ok reports if every value read so far was present and well formed.
+func (v *values) ok() bool {
+	return !v.bad
+}
+
+// next returns the next value.
+func (v *values) next() []byte {
+	if v.end {
+		v.bad = true
+		return nil
+	}
+	i := bytes.IndexByte(v.b, ',')
+	if i < 0 {
+		b := v.b
+		v.b, v.end = nil, true
+		return b
+	}
+	b := v.b[:i]
+	v.b = v.b[i+1:]
+	return b
+}
+
+// skip reads a value the event doesn't need.
+func (v *values) skip() {
+	v.next()
+}
+
+// int reads a decimal number the way strconv.Atoi does, but only up to 9 digits so it can't overflow even a 32 bit int.
+// Longer numbers are left to Parse.  The digits are parsed as they're scanned for the end of the value.
+func (v *values) int() int {
+	if v.end {
+		v.bad = true
+		return 0
+	}
+	b := v.b
+	i, neg := 0, false
+	if len(b) > 0 && (b[0] == '-' || b[0] == '+') {
+		neg = b[0] == '-'
+		i++
+	}
+	start, n := i, 0
+	for ; i < len(b) && b[i] != ','; i++ {
+		c := b[i]
+		if c < '0' || c > '9' {
+			v.bad = true
+			return 0
+		}
+		n = n*10 + int(c-'0')
+	}
+	if i == start || i-start > 9 {
+		v.bad = true
+		return 0
+	}
+	if i == len(b) {
+		v.b, v.end = nil, true
+	} else {
+		v.b = b[i+1:]
+	}
+	if neg {
+		n = -n
+	}
+	return n
+}
+
+// flag reads True or False, the only booleans sent by the stats service.
+func (v *values) flag() bool {
+	switch string(v.next()) {
+	case "True":
+		return true
+	case "False":
+		return false
+	}
+	v.bad = true
+	return false
+}
+
+// seconds reads a number of seconds, such as a game's duration, in the same way as time.ParseDuration so the results
+// match Parse to the nanosecond.  Only numbers with up to 9 digits before and after the decimal point are read here.
+func (v *values) seconds() time.Duration {
+	b := v.next()
+	i, whole := 0, int64(0)
+	for ; i < len(b) && b[i] >= '0' && b[i] <= '9'; i++ {
+		whole = whole*10 + int64(b[i]-'0')
+	}
+	if i == 0 || i > 9 {
+		v.bad = true
+		return 0
+	}
+	d := whole * int64(time.Second)
+	if i == len(b) {
+		return time.Duration(d)
+	}
+	if b[i] != '.' {
+		v.bad = true
+		return 0
+	}
+	b = b[i+1:]
+	frac, scale := int64(0), 1.0
+	for i = 0; i < len(b) && b[i] >= '0' && b[i] <= '9'; i++ {
+		frac = frac*10 + int64(b[i]-'0')
+		scale *= 10
+	}
+	if i == 0 || i > 9 || i != len(b) {
+		v.bad = true
+		return 0
+	}
+	return time.Duration(d + int64(float64(frac)*(float64(time.Second)/scale)))
+}
+
+// team reads a known team.
+func (v *values) team() Team {
+	b := v.next()
+	// The stats service sends them as named, so they're checked before their case is ignored.
+	for _, t := range [...]Team{Gold, Blue, Red} {
+		if string(b) == string(t) {
+			return t
+		}
+	}
+	for _, t := range [...]Team{Gold, Blue, Red} {
+		if bytes.EqualFold(b, []byte(t)) {
+			return t
+		}
+	}
+	v.bad = true
+	return ""
+}
+
+// class reads a known class.
+func (v *values) class() Class {
+	b := v.next()
+	// The stats service sends them as named, so they're checked before their case is ignored.
+	for _, c := range [...]Class{Worker, Soldier, Queen} {
+		if string(b) == string(c) {
+			return c
+		}
+	}
+	for _, c := range [...]Class{Worker, Soldier, Queen} {
+		if bytes.EqualFold(b, []byte(c)) {
+			return c
+		}
+	}
+	v.bad = true
+	return ""
+}
+
+// buff reads a known buff.
+func (v *values) buff() Buff {
+	b := v.next()
+	// The stats service sends them as named, so they're checked before their case is ignored.
+	for _, f := range [...]Buff{Wings, Speed} {
+		if string(b) == string(f) {
+			return f
+		}
+	}
+	for _, f := range [...]Buff{Wings, Speed} {
+		if bytes.EqualFold(b, []byte(f)) {
+			return f
+		}
+	}
+	v.bad = true
+	return ""
+}
+
+// winCondition reads a known win condition.
+func (v *values) winCondition() WinCondition {
+	b := v.next()
+	for _, w := range [...]WinCondition{Military, Economic, Snail} {
+		if bytes.EqualFold(b, []byte(w)) {
+			return w
+		}
+	}
+	v.bad = true
+	return ""
+}
+
+// gameMap reads a known map, with or without its map_ prefix.
+func (v *values) gameMap() Map {
+	b := v.next()
+	for _, m := range [...]Map{Day, Night, Dusk, Twilight, BonusMilitary, BonusSnail} {
+		if bytes.EqualFold(b, []byte(m)) || bytes.EqualFold(b, []byte(strings.TrimPrefix(string(m), "map_"))) {
+			return m
+		}
+	}
+	v.bad = true
+	return ""
+}
+
+// orientation reads a known orientation, which the stats service sends as whether gold is on the left.
+func (v *values) orientation() CabOrientation {
+	b := v.next()
+	switch {
+	case bytes.EqualFold(b, []byte(BlueOnLeft)), bytes.EqualFold(b, []byte("False")):
+		return BlueOnLeft
+	case bytes.EqualFold(b, []byte(GoldOnLeft)), bytes.EqualFold(b, []byte("True")):
+		return GoldOnLeft
+	}
+	v.bad = true
+	return ""
+}
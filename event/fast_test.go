@@ -0,0 +1,219 @@
+package event
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/rickyninja/kqstat/internal/testutil"
+)
+
+// readLines returns the lines of a log, with their line endings.
+func readLines(tb testing.TB, path string) [][]byte {
+	tb.Helper()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var lines [][]byte
+	r := bufio.NewReader(bytes.NewReader(data))
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			lines = append(lines, line)
+		}
+		if err != nil {
+			return lines
+		}
+	}
+}
+
+// result is the outcome of parsing a line, including a panic, so the parsers can be compared.
+type result struct {
+	ev    Event
+	err   string
+	panic string
+}
+
+func parseResult(parse func() (Event, error)) (r result) {
+	defer func() {
+		if p := recover(); p != nil {
+			r.panic = fmt.Sprint(p)
+		}
+	}()
+	ev, err := parse()
+	r.ev = ev
+	if err != nil {
+		r.err = err.Error()
+	}
+	return r
+}
+
+// compareParsers checks that ParseBytes and d agree with Parse on line.  The same d is used for every line, so
+// anything left from the events it decoded before would show up.
+func compareParsers(t *testing.T, d *Decoder, line []byte) {
+	t.Helper()
+	want := parseResult(func() (Event, error) { return Parse(string(line)) })
+	got := parseResult(func() (Event, error) { return ParseBytes(line) })
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%q: ParseBytes got %#v, Parse got %#v", line, got, want)
+	}
+	got = parseResult(func() (Event, error) {
+		err := d.Decode(line)
+		return d.Event(), err
+	})
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%q: Decoder got %#v, Parse got %#v", line, got, want)
+	}
+}
+
+// TestParseBytes checks that ParseBytes, a Decoder and Parse agree on every line of a log, and on variations of those lines that
+// are malformed in different ways.
+func TestParseBytes(t *testing.T) {
+	// Parse logs malformed values.
+	log.SetOutput(ioutil.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	var d Decoder
+	seen := make(map[string]bool)
+	for _, line := range readLines(t, testutil.BB3) {
+		compareParsers(t, &d, line)
+		compareParsers(t, &d, bytes.TrimSuffix(line, []byte("\n")))
+		// Variations of one line of each key are enough.
+		key := line[:bytes.IndexByte(line, ']')]
+		if seen[string(key)] {
+			continue
+		}
+		seen[string(key)] = true
+		for i := range line {
+			compareParsers(t, &d, line[:i])
+			for _, b := range []byte{',', 'x', '-', '9', ']', '\r'} {
+				v := append([]byte(nil), line...)
+				v[i] = b
+				compareParsers(t, &d, v)
+			}
+		}
+	}
+	for _, line := range []string{
+		"![k[playerKill],v[1,2,3,4,worker]]!",
+		"![k[playerKill],v[+1,-2,3,4,Worker,extra]]!",
+		"![k[playerKill],v[1,2,3,4,Drone]]!",
+		"![k[playerKill],v[1,2,3,4]]!",
+		"![k[playerKill],v[1,2,3,4,Worker]],v[5]]!",
+		"![k[playerKill],v[12345678901234567890,2,3,4,Worker]]!",
+		"![k[blessMaiden],v[1,2,BLUE]]!",
+		"![k[blessMaiden],v[1,2,Purple]]!",
+		"![k[useMaiden],v[1,2,Maiden_Speed,3]]!",
+		"![k[spawn],v[1,true]]!",
+		"![k[spawn],v[1,T]]!",
+		"![k[carryFood],v[3,4]]!",
+		"![k[carryFood],v[]]!",
+		"![k[alive],v[]]!",
+		"![k[alive],v[12:00:00 PM]]!\r\n",
+		"![k[unknown],v[1]]!",
+		"![k[gamestart],v[day,TRUE,0,False]]!",
+		"![k[gamestart],v[map_moon,False,0,False]]!",
+		"![k[gameend],v[map_day,False,128.,False]]!",
+		"![k[gameend],v[map_day,False,.5,False]]!",
+		"![k[gameend],v[map_day,False,-3,False]]!",
+		"![k[gameend],v[map_day,False,0.1234567891,False]]!",
+		"![k[gameend],v[map_day,False,1234567890,False]]!",
+		"![k[gameend],v[map_day,False,61.999999999,1]]!",
+		"![k[gameend],v[map_day,Sideways,61.5,False]]!",
+		"![k[playernames],v[a,b,c,d,e,f,g,h,i]]!",
+		"![k[playernames],v[a,b,c,d,e,f,g,h,i,j,k]]!",
+		"![k[victory],v[Red,snail]]!",
+		"![k[victory],v[Purple,military]]!",
+		"![k[victory],v[Blue]]!",
+		"",
+	} {
+		compareParsers(t, &d, []byte(line))
+	}
+}
+
+func TestParseBytes_Allocs(t *testing.T) {
+	line := []byte("![k[playerKill],v[830,860,1,10,Worker]]!")
+	n := testing.AllocsPerRun(100, func() {
+		ParseBytes(line)
+	})
+	// The event is boxed in the Event interface, which is the only allocation.
+	if n > 1 {
+		t.Errorf("got %v allocations per parse, want at most 1", n)
+	}
+}
+
+func TestDecoder_Allocs(t *testing.T) {
+	lines := [][]byte{
+		[]byte("![k[playernames],v[a,b,c,d,e,f,g,h,i,j]]!"),
+		[]byte("![k[alive],v[8:00:00 PM]]!"),
+		[]byte("![k[gamestart],v[map_day,False,0,False]]!"),
+		[]byte("![k[playerKill],v[830,860,1,10,Worker]]!"),
+		[]byte("![k[useMaiden],v[560,260,maiden_wings,8]]!"),
+		[]byte("![k[gameend],v[map_day,False,61.5,False]]!"),
+		[]byte("![k[victory],v[Blue,military]]!"),
+	}
+	var d Decoder
+	n := testing.AllocsPerRun(100, func() {
+		for _, line := range lines {
+			if err := d.Decode(line); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	if n != 0 {
+		t.Errorf("got %v allocations per run, want 0", n)
+	}
+}
+
+func TestDecoder_ReusedLine(t *testing.T) {
+	var d Decoder
+	line := []byte("![k[alive],v[8:00:00 PM]]!")
+	if err := d.Decode(line); err != nil {
+		t.Fatal(err)
+	}
+	// The line is reused by the caller, such as a bufio.Scanner, once Decode returns.
+	copy(line, "![k[alive],v[9:59:59 AM]]!")
+	if got, want := d.Event(), (Alive{Time: "8:00:00 PM"}); got != want {
+		t.Errorf("got %#v want %#v", got, want)
+	}
+}
+
+// BenchmarkParse parses lines read as bytes the way callers did before ParseBytes, by converting each to a string.
+func BenchmarkParse(b *testing.B) {
+	lines := readLines(b, testutil.BB3)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			Parse(string(line))
+		}
+	}
+}
+
+func BenchmarkParseBytes(b *testing.B) {
+	lines := readLines(b, testutil.BB3)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			ParseBytes(line)
+		}
+	}
+}
+
+func BenchmarkDecoder(b *testing.B) {
+	lines := readLines(b, testutil.BB3)
+	var d Decoder
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			d.Decode(line)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package event
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Source is a stream of events, such as a live *kqstat.Client or a *Reader of a log, so the same code can handle both.
+type Source interface {
+	// GetEvent returns the next event.
+	GetEvent() (Event, error)
+	// GetRawEvent is like GetEvent, but also returns the event text as it was sent by the stats service.
+	// The text is returned even when it fails to parse, and is empty when reading fails.
+	GetRawEvent() (string, Event, error)
+}
+
+// gzipMagic begins every gzip stream.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// errIncomplete is the error of a last line that was cut off, typically because the log is still being written.
+var errIncomplete = errors.New("incomplete last line")
+
+// Reader reads events from a log of the text sent by the stats service, one event per line.
+// Logs compressed with gzip are decompressed transparently.
+//
+// Lines may end with \n or \r\n, and blank lines are skipped.
+type Reader struct {
+	in   io.Reader
+	br   *bufio.Reader
+	line int
+	// long holds lines that don't fit in br's buffer.
+	long []byte
+	// partial is set when the last line read had no line ending.
+	partial bool
+	// err is returned by every read once reading fails or reaches the end.
+	err error
+}
+
+// NewReader returns a *Reader reading a log from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{in: r}
+}
+
+// LineError is returned by Reader, and readers built on it such as record.Reader, for a line that can't be parsed.
+// Reading may continue with the next line.
+type LineError struct {
+	// Line is the line number, starting at 1.
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+// Line returns the line number of the log that the last event was read from, starting at 1.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// GetEvent returns the next event in the log, or io.EOF after the last one.
+// Lines that fail to parse return a *LineError.
+func (r *Reader) GetEvent() (Event, error) {
+	_, ev, err := r.next()
+	return ev, err
+}
+
+// GetRawEvent is like GetEvent, but also returns the event text, without its line ending.
+// The text is returned even when it fails to parse, and is empty when reading fails.
+func (r *Reader) GetRawEvent() (string, Event, error) {
+	text, ev, err := r.next()
+	return string(text), ev, err
+}
+
+// next reads and parses the next line that isn't blank.  The returned text is only valid until the next read.
+func (r *Reader) next() ([]byte, Event, error) {
+	text, err := r.ReadLine()
+	if err != nil {
+		return nil, nil, err
+	}
+	// Parse would accept a line cut off partway through its values, so check that the last one was finished.
+	if r.partial && !bytes.HasSuffix(text, []byte(valueSuffix)) {
+		return text, nil, &LineError{Line: r.line, Err: errIncomplete}
+	}
+	ev, err := ParseBytes(text)
+	if err != nil {
+		return text, ev, &LineError{Line: r.line, Err: err}
+	}
+	return text, ev, nil
+}
+
+// ReadLine returns the next line that isn't blank without parsing it, or io.EOF after the last one.  It's for logs
+// that hold more than event text, such as recordings.  The line ending is removed, and the text is only valid until
+// the next read.
+func (r *Reader) ReadLine() ([]byte, error) {
+	if r.br == nil && r.err == nil {
+		r.err = r.open()
+	}
+	for r.err == nil {
+		line, err := r.readLine()
+		if err != nil {
+			r.err = err
+			if err != io.EOF || len(line) == 0 {
+				break
+			}
+		}
+		r.line++
+		r.partial = err == io.EOF
+		text := bytes.TrimRight(line, "\r\n")
+		if len(bytes.TrimSpace(text)) == 0 {
+			continue
+		}
+		return text, nil
+	}
+	return nil, r.err
+}
+
+// open starts reading, decompressing the log when it's gzipped.
+func (r *Reader) open() error {
+	r.br = bufio.NewReader(r.in)
+	magic, err := r.br.Peek(len(gzipMagic))
+	if err != nil && err != io.EOF {
+		return err
+	}
+	if !bytes.Equal(magic, gzipMagic) {
+		return nil
+	}
+	gz, err := gzip.NewReader(r.br)
+	if err != nil {
+		return err
+	}
+	r.br = bufio.NewReader(gz)
+	return nil
+}
+
+// readLine returns the next line including its line ending, which is missing from the last line when the log doesn't
+// end with one.  The line is only valid until the next read.
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.br.ReadSlice('\n')
+	if err != bufio.ErrBufferFull {
+		return line, err
+	}
+	r.long = append(r.long[:0], line...)
+	for err == bufio.ErrBufferFull {
+		line, err = r.br.ReadSlice('\n')
+		r.long = append(r.long, line...)
+	}
+	return r.long, err
+}
@@ -0,0 +1,148 @@
+package event
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rickyninja/kqstat/internal/testutil"
+)
+
+// readAll reads every event from src, failing on any error.
+func readAll(t *testing.T, src Source) []Event {
+	t.Helper()
+	var evs []Event
+	for {
+		ev, err := src.GetEvent()
+		if err == io.EOF {
+			return evs
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		evs = append(evs, ev)
+	}
+}
+
+func TestReader_BB3(t *testing.T) {
+	t.Parallel()
+	data, err := ioutil.ReadFile(testutil.BB3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []Event
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		ev, err := Parse(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, ev)
+	}
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, in := range map[string][]byte{"plain": data, "gzip": gz.Bytes()} {
+		r := NewReader(bytes.NewReader(in))
+		got := readAll(t, r)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: events differ from Parse", name)
+		}
+		if r.Line() != len(want) {
+			t.Errorf("%s: got line %d want %d", name, r.Line(), len(want))
+		}
+	}
+}
+
+func TestReader(t *testing.T) {
+	t.Parallel()
+	names := strings.Repeat("x", 10000)
+	log := "![k[alive],v[12:39:04 PM]]!\r\n" +
+		"\n" +
+		"  \r\n" +
+		"bogus\n" +
+		"![k[carryFood],v[3]]!\n" +
+		"![k[playernames],v[" + names + ",,,,,,,,,]]!\n" +
+		"![k[spawn],v[1,False]]!\n" +
+		"![k[carryFood],v[x]]!\n" +
+		"![k[playerKill],v[830,8"
+	r := NewReader(strings.NewReader(log))
+	want := []struct {
+		line int
+		text string
+		ev   Event
+		err  string
+	}{
+		{1, "![k[alive],v[12:39:04 PM]]!", Alive{Time: "12:39:04 PM"}, ""},
+		{4, "bogus", nil, "line 4: Failed to parse line: bogus"},
+		{5, "![k[carryFood],v[3]]!", CarryFood{Who: 3}, ""},
+		{6, "![k[playernames],v[" + names + ",,,,,,,,,]]!", PlayerNames{names, "", "", "", "", "", "", "", "", ""}, ""},
+		{7, "![k[spawn],v[1,False]]!", Spawn{Who: 1}, ""},
+		{8, "![k[carryFood],v[x]]!", nil, `line 8: carryFood: failed Atoi for x: strconv.Atoi: parsing "x": invalid syntax`},
+		{9, "![k[playerKill],v[830,8", nil, "line 9: incomplete last line"},
+	}
+	for _, w := range want {
+		text, ev, err := r.GetRawEvent()
+		if w.err == "" && err != nil {
+			t.Fatalf("line %d: %s", w.line, err)
+		}
+		if w.err != "" {
+			var le *LineError
+			if !errors.As(err, &le) || err.Error() != w.err {
+				t.Errorf("line %d: got error %v want %s", w.line, err, w.err)
+			}
+		}
+		if r.Line() != w.line || text != w.text || !reflect.DeepEqual(ev, w.ev) {
+			t.Errorf("got line %d %q %#v want line %d %q %#v", r.Line(), text, ev, w.line, w.text, w.ev)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if text, _, err := r.GetRawEvent(); err != io.EOF || text != "" {
+			t.Errorf("got %q, %v want io.EOF", text, err)
+		}
+	}
+}
+
+func TestReader_LastLine(t *testing.T) {
+	t.Parallel()
+	// A finished last line is fine without a line ending.
+	r := NewReader(strings.NewReader("![k[carryFood],v[3]]!\n![k[carryFood],v[4]]!"))
+	got := readAll(t, r)
+	want := []Event{CarryFood{Who: 3}, CarryFood{Who: 4}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if evs := readAll(t, NewReader(strings.NewReader(""))); len(evs) != 0 {
+		t.Errorf("got %v from an empty log", evs)
+	}
+}
+
+func TestReader_BadGzip(t *testing.T) {
+	t.Parallel()
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	w.Write([]byte("![k[carryFood],v[3]]!\n"))
+	w.Close()
+	// The event is intact, but the stream's checksum is cut off.
+	r := NewReader(bytes.NewReader(gz.Bytes()[:gz.Len()-4]))
+	if ev, err := r.GetEvent(); err != nil || ev != (CarryFood{Who: 3}) {
+		t.Fatalf("got %v, %v", ev, err)
+	}
+	_, err := r.GetEvent()
+	if err == nil || err == io.EOF {
+		t.Fatalf("got %v, want an error from the truncated gzip stream", err)
+	}
+	if _, err2 := r.GetEvent(); err2 != err {
+		t.Errorf("got %v after a read error, want %v again", err2, err)
+	}
+}
@@ -0,0 +1,117 @@
+// kqexport converts recordings into a CSV or Parquet file per table, for spreadsheets and data analysis tools.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/rickyninja/kqstat/export"
+	"github.com/rickyninja/kqstat/player"
+	"github.com/rickyninja/kqstat/record"
+)
+
+func main() {
+	logger := log.New(os.Stderr, "", 0)
+	var (
+		out      string
+		format   string
+		roster   string
+		realOnly bool
+	)
+	flag.StringVar(&out, "out", ".", "directory to write tables in")
+	flag.StringVar(&format, "format", "csv", "table format, csv or parquet")
+	flag.StringVar(&roster, "roster", "", "roster file mapping names to players")
+	flag.BoolVar(&realOnly, "real-only", false, "drop demo and all AI games")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] recording...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var write func(io.Writer, *export.Table) error
+	switch format {
+	case "csv":
+		write = export.WriteCSV
+	case "parquet":
+		write = export.WriteParquet
+	default:
+		fmt.Fprintf(os.Stderr, "format must be csv or parquet, got %s\n", format)
+		flag.Usage()
+		os.Exit(1)
+	}
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	var ro *player.Roster
+	if roster != "" {
+		var err error
+		ro, err = player.LoadRoster(roster)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+	reg, err := player.NewRegistry(ro)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	e := export.NewExporter(reg)
+	e.RealOnly = realOnly
+	for _, path := range flag.Args() {
+		err := exportFile(e, path)
+		if err != nil {
+			logger.Fatalf("%s: %s", path, err)
+		}
+	}
+	err = os.MkdirAll(out, 0755)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	for _, t := range e.Tables() {
+		path := filepath.Join(out, t.Name+"."+format)
+		err := writeFile(path, t, write)
+		if err != nil {
+			logger.Fatalf("%s: %s", path, err)
+		}
+		logger.Printf("Wrote %d rows to %s", len(t.Rows), path)
+	}
+}
+
+// exportFile adds every event of the recording at path to e.
+func exportFile(e *export.Exporter, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r := record.NewReader(f)
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		e.Add(env)
+	}
+}
+
+// writeFile writes a table to the file at path.
+func writeFile(path string, t *export.Table, write func(io.Writer, *export.Table) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = write(f, t)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,286 @@
+// Package export converts recordings into flat tables for spreadsheets and data analysis tools.
+//
+// There's a table of games, a table of each player's stats in each game, and a table for each type of event.
+// Rows of every table have a game_id column linking them to the games table.
+//
+// Tables can be written as CSV or Parquet.  It's a separate module from kqstat, so that the Parquet
+// library's dependencies aren't required by users of the rest of kqstat.
+package export
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/player"
+	"github.com/rickyninja/kqstat/record"
+)
+
+// Kind is the type of the values in a column.
+type Kind int
+
+const (
+	// String values are string.
+	String Kind = iota
+	// Int values are int64.
+	Int
+	// Bool values are bool.
+	Bool
+	// Time values are time.Time.
+	Time
+)
+
+// Column describes a column of a table.
+type Column struct {
+	Name string
+	Kind Kind
+}
+
+// Table is a named table of rows.  Each value in a row is of its column's Kind, or nil when it's missing.
+type Table struct {
+	Name    string
+	Columns []Column
+	Rows    [][]interface{}
+}
+
+// Exporter accumulates the tables for a stream of events.
+//
+// Alive and playernames events don't get a table of their own; the player names are in the players table.
+// Events outside of any game have a nil game_id.
+type Exporter struct {
+	// RealOnly drops demo and all AI games, along with their events, as classified by game.Segment.Kind.
+	RealOnly bool
+	registry *player.Registry
+	splitter game.Splitter
+	// pending are the events received since the last game ended.
+	pending []record.Envelope
+	games   *Table
+	players *Table
+	events  map[reflect.Type]*Table
+}
+
+// NewExporter returns an empty *Exporter, which names players according to registry.
+func NewExporter(registry *player.Registry) *Exporter {
+	return &Exporter{
+		registry: registry,
+		games: &Table{
+			Name: "games",
+			Columns: []Column{
+				{"game_id", Int},
+				{"started", Time},
+				{"ended", Time},
+				{"map", String},
+				{"orientation", String},
+				{"duration_ms", Int},
+				{"winner", String},
+				{"win_condition", String},
+				{"kind", String},
+			},
+		},
+		players: &Table{
+			Name: "players",
+			Columns: []Column{
+				{"game_id", Int},
+				{"position", String},
+				{"team", String},
+				{"player_id", String},
+				{"player", String},
+				{"won", Bool},
+				{"kills", Int},
+				{"deaths", Int},
+				{"queen_kills", Int},
+				{"berries", Int},
+				{"kick_ins", Int},
+				{"snail_distance", Int},
+			},
+		},
+		events: make(map[reflect.Type]*Table),
+	}
+}
+
+// Add adds the next event.
+func (e *Exporter) Add(env record.Envelope) {
+	e.pending = append(e.pending, env)
+	seg, ok := e.splitter.Add(env)
+	if !ok {
+		return
+	}
+	// A game's events are always the most recent ones received.
+	n := len(e.pending) - len(seg.Events)
+	for _, env := range e.pending[:n] {
+		e.addEvent(nil, [10]player.Player{}, env)
+	}
+	e.pending = nil
+	if e.RealOnly && seg.Kind() != game.Real {
+		return
+	}
+	e.addGame(seg)
+}
+
+// Tables returns the games table, the players table, and then a table for each type of event, ordered by name.
+// Events since the last game ended are included without a game_id.
+func (e *Exporter) Tables() []*Table {
+	for _, env := range e.pending {
+		e.addEvent(nil, [10]player.Player{}, env)
+	}
+	e.pending = nil
+	tables := []*Table{e.games, e.players}
+	var events []*Table
+	for _, t := range e.events {
+		events = append(events, t)
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Name < events[j].Name
+	})
+	return append(tables, events...)
+}
+
+// addGame adds the rows for a completed game.
+func (e *Exporter) addGame(seg game.Segment) {
+	id := int64(len(e.games.Rows) + 1)
+	first, last := seg.Events[0], seg.Events[len(seg.Events)-1]
+	e.games.Rows = append(e.games.Rows, []interface{}{
+		id,
+		timeValue(first.Time),
+		timeValue(last.Time),
+		stringValue(string(seg.Start.Map)),
+		stringValue(string(seg.Start.Orientation)),
+		int64(seg.End.Duration / time.Millisecond),
+		stringValue(string(seg.Victory.Team)),
+		stringValue(string(seg.Victory.Type)),
+		seg.Kind().String(),
+	})
+	var lineup [10]player.Player
+	for i := range lineup {
+		if i < len(seg.Names) {
+			lineup[i] = e.registry.Resolve(seg.Names[i])
+		}
+	}
+	stats := game.Summarize(seg)
+	for i, ps := range stats.Players {
+		p := lineup[i]
+		team := ps.Bee.Team()
+		e.players.Rows = append(e.players.Rows, []interface{}{
+			id,
+			ps.Bee.String(),
+			string(team),
+			stringValue(p.ID),
+			stringValue(p.Name),
+			team == seg.Victory.Team,
+			int64(ps.Kills),
+			int64(ps.Deaths),
+			int64(ps.QueenKills),
+			int64(ps.Berries),
+			int64(ps.KickIns),
+			int64(ps.SnailDistance),
+		})
+	}
+	for _, env := range seg.Events {
+		e.addEvent(id, lineup, env)
+	}
+}
+
+// addEvent adds a row for an event to the table for its type.  gameID is nil for events outside of a game.
+func (e *Exporter) addEvent(gameID interface{}, lineup [10]player.Player, env record.Envelope) {
+	switch env.Event.(type) {
+	case nil, event.Alive, event.PlayerNames:
+		return
+	}
+	v := reflect.ValueOf(env.Event)
+	t := e.table(v.Type())
+	row := []interface{}{gameID, int64(env.Seq), timeValue(env.Time)}
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		switch x := f.Interface().(type) {
+		case event.Bee:
+			row = append(row, x.String(), stringValue(who(lineup, x).Name))
+		case time.Duration:
+			row = append(row, int64(x/time.Millisecond))
+		case bool:
+			row = append(row, x)
+		default:
+			switch f.Kind() {
+			case reflect.Int:
+				row = append(row, f.Int())
+			default:
+				row = append(row, stringValue(f.String()))
+			}
+		}
+	}
+	t.Rows = append(t.Rows, row)
+}
+
+// table returns the table for events of type typ, creating it if needed.
+func (e *Exporter) table(typ reflect.Type) *Table {
+	if t, ok := e.events[typ]; ok {
+		return t
+	}
+	t := &Table{
+		Name:    snake(typ.Name()),
+		Columns: []Column{{"game_id", Int}, {"seq", Int}, {"time", Time}},
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		name := snake(f.Name)
+		switch {
+		case f.Type == reflect.TypeOf(event.Bee(0)):
+			// Positions are given by name, along with the name of the player in that position.
+			t.Columns = append(t.Columns, Column{name, String}, Column{name + "_player", String})
+		case f.Type == reflect.TypeOf(time.Duration(0)):
+			t.Columns = append(t.Columns, Column{name + "_ms", Int})
+		case f.Type.Kind() == reflect.Bool:
+			t.Columns = append(t.Columns, Column{name, Bool})
+		case f.Type.Kind() == reflect.Int:
+			t.Columns = append(t.Columns, Column{name, Int})
+		default:
+			t.Columns = append(t.Columns, Column{name, String})
+		}
+	}
+	e.events[typ] = t
+	return t
+}
+
+// who returns the player in a position of lineup.
+func who(lineup [10]player.Player, b event.Bee) player.Player {
+	if !b.Valid() {
+		return player.Player{}
+	}
+	return lineup[b-1]
+}
+
+// stringValue returns s, or nil when it's empty.
+func stringValue(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// timeValue returns t, or nil when it's the zero time.
+func timeValue(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// snake converts a Go name like SlainClass or IsAI to snake case like slain_class or is_ai.
+func snake(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower && unicode.IsUpper(runes[i-1]) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -0,0 +1,252 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/rickyninja/kqstat/internal/testutil"
+	"github.com/rickyninja/kqstat/player"
+	"github.com/rickyninja/kqstat/record"
+)
+
+func exportFile(t *testing.T, path string) []*Table {
+	t.Helper()
+	reg, err := player.NewRegistry(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	e := NewExporter(reg)
+	r := record.NewReader(f)
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Add(env)
+	}
+	return e.Tables()
+}
+
+func find(t *testing.T, tables []*Table, name string) *Table {
+	t.Helper()
+	for _, tb := range tables {
+		if tb.Name == name {
+			return tb
+		}
+	}
+	t.Fatalf("no %s table", name)
+	return nil
+}
+
+func column(t *testing.T, tb *Table, name string) int {
+	t.Helper()
+	for i, c := range tb.Columns {
+		if c.Name == name {
+			return i
+		}
+	}
+	t.Fatalf("no %s column in %s", name, tb.Name)
+	return -1
+}
+
+func TestExporter(t *testing.T) {
+	tables := exportFile(t, testutil.BB3)
+	if tables[0].Name != "games" || tables[1].Name != "players" {
+		t.Fatalf("got tables %s, %s first want games, players", tables[0].Name, tables[1].Name)
+	}
+	games := find(t, tables, "games")
+	if len(games.Rows) != 17 {
+		t.Errorf("got %d games want 17", len(games.Rows))
+	}
+	players := find(t, tables, "players")
+	if len(players.Rows) != 17*10 {
+		t.Errorf("got %d players want %d", len(players.Rows), 17*10)
+	}
+	for _, tb := range tables {
+		for i, row := range tb.Rows {
+			if len(row) != len(tb.Columns) {
+				t.Fatalf("%s row %d has %d values want %d", tb.Name, i, len(row), len(tb.Columns))
+			}
+		}
+	}
+	for _, name := range []string{"alive", "player_names"} {
+		for _, tb := range tables {
+			if tb.Name == name {
+				t.Errorf("unexpected %s table", name)
+			}
+		}
+	}
+
+	kills := find(t, tables, "player_kill")
+	want := []string{"game_id", "seq", "time", "x", "y", "slayer", "slayer_player", "slain", "slain_player", "slain_class"}
+	var got []string
+	for _, c := range kills.Columns {
+		got = append(got, c.Name)
+	}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got player_kill columns %v want %v", got, want)
+	}
+	gameID, slayer := column(t, kills, "game_id"), column(t, kills, "slayer")
+	inGame := 0
+	for _, row := range kills.Rows {
+		if id, ok := row[gameID].(int64); ok {
+			inGame++
+			if id < 1 || id > int64(len(games.Rows)) {
+				t.Errorf("kill has game_id %d without a game", id)
+			}
+		}
+		if s, _ := row[slayer].(string); !strings.HasPrefix(s, "gold-") && !strings.HasPrefix(s, "blue-") {
+			t.Errorf("got slayer %v want a position name", row[slayer])
+		}
+	}
+	if inGame == 0 || inGame == len(kills.Rows) {
+		// The first game of the recording began before it did, so some kills are outside of any game.
+		t.Errorf("got %d of %d kills in games", inGame, len(kills.Rows))
+	}
+
+	spawn := find(t, tables, "spawn")
+	if c := spawn.Columns[column(t, spawn, "is_ai")]; c.Kind != Bool {
+		t.Errorf("got is_ai kind %d want Bool", c.Kind)
+	}
+	end := find(t, tables, "game_end")
+	column(t, end, "duration_ms")
+	kind := column(t, games, "kind")
+	for i, row := range games.Rows {
+		if row[kind] != "real" {
+			t.Errorf("game %d: got kind %v want real", i, row[kind])
+		}
+	}
+}
+
+func TestExporter_RealOnly(t *testing.T) {
+	reg, err := player.NewRegistry(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := NewExporter(reg)
+	e.RealOnly = true
+	r := record.NewReader(strings.NewReader(`![k[playernames],v[,,,,,,,,,]]!
+![k[gamestart],v[map_day,False,0,True]]!
+![k[playerKill],v[1190,860,8,5,Worker]]!
+![k[gameend],v[map_day,False,30.5,True]]!
+![k[victory],v[Blue,military]]!
+![k[playernames],v[a,b,c,d,e,f,g,h,i,j]]!
+![k[gamestart],v[map_night,False,0,False]]!
+![k[playerKill],v[750,861,1,2,Queen]]!
+![k[gameend],v[map_night,False,60.5,False]]!
+![k[victory],v[Gold,military]]!
+`))
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Add(env)
+	}
+	tables := e.Tables()
+	if games := find(t, tables, "games"); len(games.Rows) != 1 {
+		t.Fatalf("got %d games want 1", len(games.Rows))
+	}
+	kills := find(t, tables, "player_kill")
+	if len(kills.Rows) != 1 || kills.Rows[0][column(t, kills, "slain")] != "blue-queen" {
+		t.Errorf("got kills %v want only the real game's", kills.Rows)
+	}
+}
+
+func TestSnake(t *testing.T) {
+	tests := map[string]string{
+		"X":            "x",
+		"SlainClass":   "slain_class",
+		"IsAI":         "is_ai",
+		"GetOnSnail":   "get_on_snail",
+		"BerryKickIn":  "berry_kick_in",
+		"HTTPResponse": "http_response",
+	}
+	for in, want := range tests {
+		if got := snake(in); got != want {
+			t.Errorf("snake(%s) got %s want %s", in, got, want)
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	tables := exportFile(t, testutil.BB3)
+	players := find(t, tables, "players")
+	var buf bytes.Buffer
+	err := WriteCSV(&buf, players)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(players.Rows)+1 {
+		t.Fatalf("got %d records want %d", len(records), len(players.Rows)+1)
+	}
+	if records[0][0] != "game_id" {
+		t.Errorf("got first column %s want game_id", records[0][0])
+	}
+	if records[1][0] != "1" || records[1][1] != "gold-queen" {
+		t.Errorf("got first row %v", records[1])
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	tables := exportFile(t, testutil.BB3)
+	kills := find(t, tables, "player_kill")
+	var buf bytes.Buffer
+	err := WriteParquet(&buf, kills)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.NumRows() != int64(len(kills.Rows)) {
+		t.Fatalf("got %d rows want %d", f.NumRows(), len(kills.Rows))
+	}
+	type kill struct {
+		GameID *int64  `parquet:"game_id,optional"`
+		X      *int64  `parquet:"x,optional"`
+		Slayer *string `parquet:"slayer,optional"`
+	}
+	rows := make([]kill, len(kills.Rows))
+	n, err := parquet.NewGenericReader[kill](bytes.NewReader(buf.Bytes())).Read(rows)
+	if err != nil && err != io.EOF {
+		t.Fatal(err)
+	}
+	if n != len(rows) {
+		t.Fatalf("read %d rows want %d", n, len(rows))
+	}
+	gameID, x, slayer := column(t, kills, "game_id"), column(t, kills, "x"), column(t, kills, "slayer")
+	for i, want := range kills.Rows {
+		got := rows[i]
+		if (got.GameID == nil) != (want[gameID] == nil) || got.GameID != nil && *got.GameID != want[gameID].(int64) {
+			t.Fatalf("row %d got game_id %v want %v", i, got.GameID, want[gameID])
+		}
+		if got.X == nil || *got.X != want[x].(int64) {
+			t.Fatalf("row %d got x %v want %v", i, got.X, want[x])
+		}
+		if got.Slayer == nil || *got.Slayer != want[slayer].(string) {
+			t.Fatalf("row %d got slayer %v want %v", i, got.Slayer, want[slayer])
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// WriteParquet writes a table to w as a Parquet file.  Every column is optional, so missing values are null.
+// Times are timestamps in microseconds, adjusted to UTC.
+func WriteParquet(w io.Writer, t *Table) error {
+	group := parquet.Group{}
+	for _, c := range t.Columns {
+		var node parquet.Node
+		switch c.Kind {
+		case String:
+			node = parquet.String()
+		case Int:
+			node = parquet.Int(64)
+		case Bool:
+			node = parquet.Leaf(parquet.BooleanType)
+		case Time:
+			node = parquet.Timestamp(parquet.Microsecond)
+		default:
+			return fmt.Errorf("column %s has unknown kind %d", c.Name, c.Kind)
+		}
+		group[c.Name] = parquet.Optional(node)
+	}
+	schema := parquet.NewSchema(t.Name, group)
+	// The schema orders columns by name, rather than in the table's order.
+	index := make([]int, len(t.Columns))
+	for i, c := range t.Columns {
+		leaf, ok := schema.Lookup(c.Name)
+		if !ok {
+			return fmt.Errorf("column %s is missing from the schema", c.Name)
+		}
+		index[i] = leaf.ColumnIndex
+	}
+	pw := parquet.NewWriter(w, schema, parquet.Compression(&parquet.Snappy))
+	rows := make([]parquet.Row, 0, len(t.Rows))
+	for _, r := range t.Rows {
+		row := make(parquet.Row, len(t.Columns))
+		for i, v := range r {
+			j := index[i]
+			if v == nil {
+				row[j] = parquet.NullValue().Level(0, 0, j)
+				continue
+			}
+			row[j] = parquetValue(v).Level(0, 1, j)
+		}
+		rows = append(rows, row)
+	}
+	_, err := pw.WriteRows(rows)
+	if err != nil {
+		return err
+	}
+	return pw.Close()
+}
+
+// parquetValue converts a value to its Parquet representation.
+func parquetValue(v interface{}) parquet.Value {
+	switch x := v.(type) {
+	case string:
+		return parquet.ByteArrayValue([]byte(x))
+	case int64:
+		return parquet.Int64Value(x)
+	case bool:
+		return parquet.BooleanValue(x)
+	case time.Time:
+		return parquet.Int64Value(x.UnixNano() / int64(time.Microsecond))
+	}
+	return parquet.ByteArrayValue([]byte(fmt.Sprint(v)))
+}
@@ -0,0 +1,54 @@
+package game
+
+import "github.com/rickyninja/kqstat/event"
+
+// Kind is whether a game was played by people, or is noise from a cabinet left idle.
+type Kind int
+
+const (
+	// Real games are played by people, though some positions may be AI.
+	Real Kind = iota
+	// Demo games are played by the cabinet's attract mode while it's idle.
+	Demo
+	// AllAI games had only AI players, such as when the cabinet starts a game nobody joined.
+	AllAI
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Real:
+		return "real"
+	case Demo:
+		return "demo"
+	case AllAI:
+		return "all-ai"
+	}
+	return "unknown"
+}
+
+// Kind classifies the game.  A game is a demo when its gamestart or gameend is flagged as attract mode, or the Red team
+// won, since Red isn't a team people play on.  A game is all AI when every position that spawned was AI.
+// Red gates aren't a sign of the demo, since the cabinet sends gold's gates as Red in every game.
+func (s Segment) Kind() Kind {
+	if s.Start.Attract || s.End.Attract || s.Victory.Team == event.Red {
+		return Demo
+	}
+	// Only a position's latest spawn counts, since a person may take over from the AI.
+	var spawned, ai [10]bool
+	for _, sp := range s.Spawns {
+		if sp.Who.Valid() {
+			spawned[sp.Who-1] = true
+			ai[sp.Who-1] = sp.IsAI
+		}
+	}
+	kind := Real
+	for i := range spawned {
+		if spawned[i] && !ai[i] {
+			return Real
+		}
+		if spawned[i] {
+			kind = AllAI
+		}
+	}
+	return kind
+}
@@ -0,0 +1,74 @@
+package game
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rickyninja/kqstat/internal/testutil"
+	"github.com/rickyninja/kqstat/record"
+)
+
+func TestKind_Real(t *testing.T) {
+	t.Parallel()
+	for i, g := range splitFile(t, testutil.BB3) {
+		if k := g.Kind(); k != Real {
+			t.Errorf("game %d: got %s want %s", i, k, Real)
+		}
+		// Every position spawns before each game, mostly before its playernames event.
+		if len(g.Spawns) < 10 {
+			t.Errorf("game %d: got %d spawns, want at least 10", i, len(g.Spawns))
+		}
+	}
+}
+
+func TestKind(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		name string
+		log  string
+		want Kind
+	}{
+		{"attract", `![k[playernames],v[,,,,,,,,,]]!
+![k[gamestart],v[map_day,False,0,True]]!
+![k[gameend],v[map_day,False,30.5,True]]!
+![k[victory],v[Blue,military]]!
+`, Demo},
+		{"red victory", `![k[playernames],v[,,,,,,,,,]]!
+![k[gamestart],v[map_day,False,0,False]]!
+![k[victory],v[Red,military]]!
+`, Demo},
+		{"all ai", `![k[spawn],v[1,True]]!
+![k[spawn],v[2,True]]!
+![k[playernames],v[,,,,,,,,,]]!
+![k[spawn],v[3,True]]!
+![k[gamestart],v[map_day,False,0,False]]!
+![k[victory],v[Gold,military]]!
+`, AllAI},
+		{"person took over", `![k[spawn],v[1,True]]!
+![k[spawn],v[2,True]]!
+![k[playernames],v[,,,,,,,,,]]!
+![k[spawn],v[2,False]]!
+![k[gamestart],v[map_day,False,0,False]]!
+![k[victory],v[Gold,military]]!
+`, Real},
+		{"red gates", `![k[playernames],v[,,,,,,,,,]]!
+![k[spawn],v[1,False]]!
+![k[blessMaiden],v[1510,860,Red]]!
+![k[gamestart],v[map_day,False,0,False]]!
+![k[blessMaiden],v[960,500,Red]]!
+![k[victory],v[Gold,military]]!
+`, Real},
+	}
+	for _, tc := range cases {
+		games, err := Split(record.NewReader(strings.NewReader(tc.log)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(games) != 1 {
+			t.Fatalf("%s: got %d games want 1", tc.name, len(games))
+		}
+		if k := games[0].Kind(); k != tc.want {
+			t.Errorf("%s: got %s want %s", tc.name, k, tc.want)
+		}
+	}
+}
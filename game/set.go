@@ -0,0 +1,243 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rickyninja/kqstat/event"
+)
+
+// SetGame is the result of a game played as part of a Set.
+type SetGame struct {
+	// Map is which map the game was played on.
+	Map event.Map
+	// Orientation is how the cabs were positioned next to each other.
+	Orientation event.CabOrientation
+	// Duration is how long the game lasted.
+	Duration time.Duration
+	// Victory is how the game was won.
+	Victory event.Victory
+	// GoldSide is the index into Set.Sides of the side that played gold.
+	GoldSide int
+	// SideSwap reports if the cab orientation changed since the previous game of the set.
+	SideSwap bool
+}
+
+// WinningSide returns the index into Set.Sides of the side that won the game, or -1 if neither did.
+func (g SetGame) WinningSide() int {
+	switch g.Victory.Team {
+	case event.Gold:
+		return g.GoldSide
+	case event.Blue:
+		return 1 - g.GoldSide
+	}
+	return -1
+}
+
+// Set is a series of games played between the same two sides.
+type Set struct {
+	// Sides are the player names of each side, sorted.  Side 0 played gold in the first game of the set.
+	Sides [2][]string
+	// Games are the games of the set in the order they were played.
+	Games []SetGame
+	// Wins is how many games each side has won.
+	Wins [2]int
+	// Winner is the index into Sides of the side that won the set, or -1 until one has.
+	Winner int
+}
+
+// SideSwaps returns how many times the cab orientation changed during the set.
+func (s *Set) SideSwaps() int {
+	n := 0
+	for _, g := range s.Games {
+		if g.SideSwap {
+			n++
+		}
+	}
+	return n
+}
+
+// Score returns the set score, such as "2-1", from the point of view of side 0.
+func (s *Set) Score() string {
+	return fmt.Sprintf("%d-%d", s.Wins[0], s.Wins[1])
+}
+
+// SetTracker groups consecutive games into sets.
+//
+// A new set begins when the players change, when the previous set is over, or when the map rotation starts over.
+// When Rotation is set, a set lasts until BestOf games have been played or the rotation starts over, even if it
+// was decided sooner.  Otherwise a set ends as soon as either side has won it.
+type SetTracker struct {
+	// BestOf is the length of a series, such as 3 for best of three.
+	BestOf int
+	// Rotation is the order maps are played in during a set, such as Day, Night, Dusk.  It may be empty.
+	Rotation []event.Map
+	sets     []*Set
+}
+
+// NewSetTracker returns a *SetTracker for best of bestOf series, played on maps in the order of rotation.
+func NewSetTracker(bestOf int, rotation ...event.Map) *SetTracker {
+	return &SetTracker{
+		BestOf:   bestOf,
+		Rotation: rotation,
+	}
+}
+
+// ParseRotation parses a comma separated list of maps, such as "day,night,dusk", for SetTracker.Rotation.
+// Map names may have their map_ prefix, and unknown maps are an error.
+func ParseRotation(list string) ([]event.Map, error) {
+	var maps []event.Map
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		m, err := event.ParseMap(name, true)
+		if err != nil {
+			return nil, err
+		}
+		maps = append(maps, m)
+	}
+	return maps, nil
+}
+
+// Add adds a completed game, and returns the set it was added to.
+func (t *SetTracker) Add(seg Segment) *Set {
+	sides := teamNames(seg.Names)
+	cur := t.Current()
+	if t.isNewSet(cur, seg, sides) {
+		cur = &Set{Sides: sides, Winner: -1}
+		t.sets = append(t.sets, cur)
+	}
+	orientation := seg.Start.Orientation
+	if orientation == "" {
+		orientation = seg.End.Orientation
+	}
+	g := SetGame{
+		Map:         seg.Start.Map,
+		Orientation: orientation,
+		Duration:    seg.End.Duration,
+		Victory:     seg.Victory,
+	}
+	if n := len(cur.Games); n > 0 {
+		prev := cur.Games[n-1]
+		g.SideSwap = prev.Orientation != g.Orientation
+		g.GoldSide = prev.GoldSide
+		switch {
+		case known(sides[0]) && equal(sides[0], cur.Sides[1]):
+			g.GoldSide = 1
+		case known(sides[0]) && equal(sides[0], cur.Sides[0]):
+			g.GoldSide = 0
+		case g.SideSwap:
+			// Without names, assume the players stayed put while the cabs switched colors.
+			g.GoldSide = 1 - prev.GoldSide
+		}
+	}
+	cur.Games = append(cur.Games, g)
+	if w := g.WinningSide(); w >= 0 {
+		cur.Wins[w]++
+		if cur.Winner < 0 && cur.Wins[w] >= t.needed() {
+			cur.Winner = w
+		}
+	}
+	return cur
+}
+
+// Current returns the set most recently added to, or nil if no games have been added.
+func (t *SetTracker) Current() *Set {
+	if len(t.sets) == 0 {
+		return nil
+	}
+	return t.sets[len(t.sets)-1]
+}
+
+// NextMap returns the map the next game of the current set should be played on according to Rotation,
+// or an empty Map when there's no Rotation.
+func (t *SetTracker) NextMap() event.Map {
+	if len(t.Rotation) == 0 {
+		return ""
+	}
+	cur := t.Current()
+	if cur == nil || t.BestOf > 0 && len(cur.Games) >= t.BestOf {
+		return t.Rotation[0]
+	}
+	return t.Rotation[len(cur.Games)%len(t.Rotation)]
+}
+
+// Sets returns every set in the order they were played.
+func (t *SetTracker) Sets() []*Set {
+	return t.sets
+}
+
+// isNewSet reports if seg begins a new set, rather than continuing cur.
+func (t *SetTracker) isNewSet(cur *Set, seg Segment, sides [2][]string) bool {
+	if cur == nil || len(cur.Games) == 0 {
+		return true
+	}
+	if !samePlayers(cur.Sides, sides) {
+		return true
+	}
+	if len(t.Rotation) == 0 {
+		return cur.Winner >= 0
+	}
+	if t.BestOf > 0 && len(cur.Games) >= t.BestOf {
+		return true
+	}
+	return seg.Start.Map == t.Rotation[0]
+}
+
+// needed returns how many wins it takes to win a set.
+func (t *SetTracker) needed() int {
+	if t.BestOf <= 0 {
+		return 1
+	}
+	return t.BestOf/2 + 1
+}
+
+// teamNames returns the sorted names of the gold and blue players.
+func teamNames(names event.PlayerNames) [2][]string {
+	var sides [2][]string
+	for i, n := range names {
+		if n == "" {
+			continue
+		}
+		switch event.Bee(i + 1).Team() {
+		case event.Gold:
+			sides[0] = append(sides[0], n)
+		case event.Blue:
+			sides[1] = append(sides[1], n)
+		}
+	}
+	sort.Strings(sides[0])
+	sort.Strings(sides[1])
+	return sides
+}
+
+// samePlayers reports if two sets of sides have the same players, regardless of which side they're on.
+// Sides without names are unknown, and assumed to be the same.
+func samePlayers(a, b [2][]string) bool {
+	if !known(a[0]) && !known(a[1]) || !known(b[0]) && !known(b[1]) {
+		return true
+	}
+	return equal(a[0], b[0]) && equal(a[1], b[1]) || equal(a[0], b[1]) && equal(a[1], b[0])
+}
+
+// known reports if any names are known for a side.
+func known(names []string) bool {
+	return len(names) > 0
+}
+
+// equal reports if two sorted lists of names are the same.
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package game
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/internal/testutil"
+)
+
+func TestSetTracker_Rotation(t *testing.T) {
+	t.Parallel()
+	tr := NewSetTracker(3, event.Day, event.Night, event.Dusk)
+	for _, g := range splitFile(t, testutil.BB3) {
+		tr.Add(g)
+	}
+	sets := tr.Sets()
+	lengths := []int{1, 3, 3, 3, 1, 3, 3}
+	if len(sets) != len(lengths) {
+		t.Fatalf("wrong number of sets, got %d want %d", len(sets), len(lengths))
+	}
+	for i, n := range lengths {
+		if len(sets[i].Games) != n {
+			t.Errorf("set %d: wrong number of games, got %d want %d", i, len(sets[i].Games), n)
+		}
+	}
+	// Blue won the day, night and dusk games of the second set.
+	s := sets[1]
+	if s.Score() != "0-3" {
+		t.Errorf("wrong Score, got %s want %s", s.Score(), "0-3")
+	}
+	if s.Winner != 1 {
+		t.Errorf("wrong Winner, got %d want %d", s.Winner, 1)
+	}
+	if s.SideSwaps() != 0 {
+		t.Errorf("wrong SideSwaps, got %d want %d", s.SideSwaps(), 0)
+	}
+	if got := tr.NextMap(); got != event.Day {
+		t.Errorf("wrong NextMap, got %s want %s", got, event.Day)
+	}
+}
+
+func TestSetTracker_BestOf(t *testing.T) {
+	t.Parallel()
+	tr := NewSetTracker(3)
+	for _, g := range splitFile(t, testutil.BB3) {
+		tr.Add(g)
+	}
+	for i, s := range tr.Sets() {
+		if s.Winner < 0 && i != len(tr.Sets())-1 {
+			t.Errorf("set %d ended without a winner", i)
+		}
+		if len(s.Games) > 3 {
+			t.Errorf("set %d has too many games: %d", i, len(s.Games))
+		}
+	}
+}
+
+func TestSetTracker_Sides(t *testing.T) {
+	t.Parallel()
+	abcde := event.PlayerNames{"a", "f", "b", "g", "c", "h", "d", "i", "e", "j"}
+	fghij := event.PlayerNames{"f", "a", "g", "b", "h", "c", "i", "d", "j", "e"}
+	other := event.PlayerNames{"k", "l", "m", "n", "o", "p", "q", "r", "s", "t"}
+	tr := NewSetTracker(3)
+	add := func(names event.PlayerNames, or event.CabOrientation, winner event.Team) *Set {
+		return tr.Add(Segment{
+			Names:   names,
+			Start:   event.GameStart{Map: event.Day, Orientation: or},
+			Victory: event.Victory{Team: winner, Type: event.Military},
+		})
+	}
+	add(abcde, event.BlueOnLeft, event.Gold)
+	s := add(fghij, event.GoldOnLeft, event.Gold)
+	if s.Games[1].GoldSide != 1 {
+		t.Errorf("wrong GoldSide after swapping colors, got %d want %d", s.Games[1].GoldSide, 1)
+	}
+	if !s.Games[1].SideSwap {
+		t.Error("expected a side swap")
+	}
+	if s.Score() != "1-1" {
+		t.Errorf("wrong Score, got %s want %s", s.Score(), "1-1")
+	}
+	s = add(abcde, event.BlueOnLeft, event.Blue)
+	if s.Winner != 1 {
+		t.Errorf("wrong Winner, got %d want %d", s.Winner, 1)
+	}
+	if len(tr.Sets()) != 1 {
+		t.Errorf("wrong number of sets, got %d want %d", len(tr.Sets()), 1)
+	}
+	add(other, event.BlueOnLeft, event.Blue)
+	if len(tr.Sets()) != 2 {
+		t.Errorf("new players should start a new set, got %d sets", len(tr.Sets()))
+	}
+}
+
+func TestSetTracker_SideSwapWithoutNames(t *testing.T) {
+	t.Parallel()
+	tr := NewSetTracker(5)
+	tr.Add(Segment{Start: event.GameStart{Orientation: event.BlueOnLeft}, Victory: event.Victory{Team: event.Gold}})
+	s := tr.Add(Segment{Start: event.GameStart{Orientation: event.GoldOnLeft}, Victory: event.Victory{Team: event.Blue}})
+	if s.Score() != "2-0" {
+		t.Errorf("wrong Score, got %s want %s", s.Score(), "2-0")
+	}
+}
+
+func TestParseRotation(t *testing.T) {
+	t.Parallel()
+	got, err := ParseRotation(" day, map_Night,,dusk ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []event.Map{event.Day, event.Night, event.Dusk}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+	if _, err := ParseRotation("day,noon"); err == nil {
+		t.Error("expected an error for an unknown map")
+	}
+}
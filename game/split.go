@@ -0,0 +1,162 @@
+// Package game groups the events sent by a stats service into games.
+package game
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/record"
+)
+
+// Segment is the events of a single game.
+type Segment struct {
+	// Names are the players from the playernames event preceding the game, and are nil when there wasn't one.
+	Names event.PlayerNames
+	// Start is the game's gamestart event.
+	Start event.GameStart
+	// End is the game's gameend event.  It's the zero GameEnd when the cabinet only sent a victory.
+	End event.GameEnd
+	// Victory is how the game was won.
+	Victory event.Victory
+	// Events are the game's events in order, from the preceding playernames event through the victory.
+	Events []record.Envelope
+	// Spawns are the spawn events since the previous game, including those sent before the playernames event,
+	// and those during the game.
+	Spawns []event.Spawn
+}
+
+// Meta summarizes the game, and is suitable for use as record.Header.Meta.
+func (s Segment) Meta() map[string]string {
+	m := map[string]string{
+		"map":           string(s.Start.Map),
+		"orientation":   string(s.Start.Orientation),
+		"winner":        string(s.Victory.Team),
+		"win_condition": string(s.Victory.Type),
+	}
+	if s.End.Duration > 0 {
+		m["duration"] = s.End.Duration.String()
+	}
+	if s.Names != nil {
+		m["players"] = strings.Join(s.Names, ",")
+	}
+	if s.Start.Attract {
+		m["attract"] = "true"
+	}
+	return m
+}
+
+// Write writes the game to w as a recording, with h as its header.  The game's summary is added to h.Meta.
+func (s Segment) Write(w io.Writer, h record.Header) error {
+	meta := s.Meta()
+	for k, v := range h.Meta {
+		meta[k] = v
+	}
+	h.Meta = meta
+	if len(s.Events) > 0 && h.Started.IsZero() {
+		h.Started = s.Events[0].Time
+	}
+	rw, err := record.NewWriter(w, h)
+	if err != nil {
+		return err
+	}
+	for _, env := range s.Events {
+		err := rw.Write(env.Time, env.Line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Splitter groups a stream of events into games.
+// A game begins at a gamestart event, along with the playernames event and anything else sent between them,
+// and ends at a victory event.  A game is aborted and discarded when a playernames or gamestart event arrives before its victory.
+type Splitter struct {
+	game   *Segment
+	pre    []record.Envelope
+	spawns []event.Spawn
+}
+
+// Pending returns how many of the most recent events added may still become part of a game.
+func (s *Splitter) Pending() int {
+	if s.game != nil {
+		return len(s.game.Events)
+	}
+	return len(s.pre)
+}
+
+// Add adds the next event in the stream, and returns the game it completes, if any.
+func (s *Splitter) Add(env record.Envelope) (Segment, bool) {
+	if v, ok := env.Event.(event.Spawn); ok {
+		if s.game != nil {
+			s.game.Spawns = append(s.game.Spawns, v)
+		} else {
+			s.spawns = append(s.spawns, v)
+		}
+	}
+	switch v := env.Event.(type) {
+	case event.PlayerNames:
+		s.game = nil
+		s.pre = []record.Envelope{env}
+		return Segment{}, false
+	case event.GameStart:
+		s.game = &Segment{
+			Start:  v,
+			Events: append(s.pre, env),
+			Spawns: s.spawns,
+		}
+		if len(s.pre) > 0 {
+			s.game.Names, _ = s.pre[0].Event.(event.PlayerNames)
+		}
+		s.pre = nil
+		s.spawns = nil
+		return Segment{}, false
+	}
+	if s.game == nil {
+		if len(s.pre) > 0 {
+			s.pre = append(s.pre, env)
+		}
+		return Segment{}, false
+	}
+	s.game.Events = append(s.game.Events, env)
+	switch v := env.Event.(type) {
+	case event.GameEnd:
+		s.game.End = v
+	case event.Victory:
+		s.game.Victory = v
+		g := *s.game
+		s.game = nil
+		return g, true
+	}
+	return Segment{}, false
+}
+
+// SplitFile returns the games completed in the recording at path.
+func SplitFile(path string) ([]Segment, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	return Split(record.NewReader(fd))
+}
+
+// Split reads every event from r, and returns the games that were completed.
+func Split(r *record.Reader) ([]Segment, error) {
+	var games []Segment
+	s := new(Splitter)
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			return games, nil
+		}
+		if err != nil {
+			return games, err
+		}
+		if g, ok := s.Add(env); ok {
+			games = append(games, g)
+		}
+	}
+}
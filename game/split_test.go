@@ -0,0 +1,156 @@
+package game
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/internal/testutil"
+	"github.com/rickyninja/kqstat/record"
+)
+
+func TestSplit(t *testing.T) {
+	t.Parallel()
+	games := splitFile(t, testutil.BB3)
+	// The log begins partway through a game, which is skipped since its gamestart is missing.
+	if len(games) != 17 {
+		t.Fatalf("wrong number of games, got %d want %d", len(games), 17)
+	}
+	maps := []event.Map{event.Day, event.Day, event.Night, event.Dusk}
+	for i, g := range games {
+		if g.Names == nil {
+			t.Errorf("game %d: missing player names", i)
+		}
+		if _, ok := g.Events[0].Event.(event.PlayerNames); !ok {
+			t.Errorf("game %d: should begin with playernames, got %s", i, g.Events[0].Line)
+		}
+		if _, ok := g.Events[len(g.Events)-1].Event.(event.Victory); !ok {
+			t.Errorf("game %d: should end with victory, got %s", i, g.Events[len(g.Events)-1].Line)
+		}
+		if i < len(maps) && g.Start.Map != maps[i] {
+			t.Errorf("game %d: wrong map, got %s want %s", i, g.Start.Map, maps[i])
+		}
+		if g.End.Map != g.Start.Map {
+			t.Errorf("game %d: gameend map %s doesn't match gamestart map %s", i, g.End.Map, g.Start.Map)
+		}
+	}
+	last := games[len(games)-1]
+	if want := (event.Victory{Team: event.Gold, Type: event.Economic}); last.Victory != want {
+		t.Errorf("wrong last victory, got %#v want %#v", last.Victory, want)
+	}
+}
+
+func TestSplit_Aborted(t *testing.T) {
+	t.Parallel()
+	r := record.NewReader(strings.NewReader(`![k[playernames],v[a,b,c,d,e,f,g,h,i,j]]!
+![k[gamestart],v[map_day,False,0,False]]!
+![k[playerKill],v[750,861,1,2,Queen]]!
+![k[alive],v[12:39:14 PM]]!
+![k[playernames],v[k,l,m,n,o,p,q,r,s,t]]!
+![k[spawn],v[1,False]]!
+![k[gamestart],v[map_night,False,0,False]]!
+![k[playerKill],v[750,861,1,2,Queen]]!
+![k[gameend],v[map_night,False,60.5,False]]!
+![k[victory],v[Gold,military]]!
+`))
+	games, err := Split(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 1 {
+		t.Fatalf("wrong number of games, got %d want %d", len(games), 1)
+	}
+	g := games[0]
+	if g.Start.Map != event.Night {
+		t.Errorf("wrong map, got %s want %s", g.Start.Map, event.Night)
+	}
+	if g.Names[0] != "k" {
+		t.Errorf("wrong player names, got %v", g.Names)
+	}
+	if len(g.Events) != 6 {
+		t.Errorf("wrong number of events, got %d want %d", len(g.Events), 6)
+	}
+}
+
+func TestSegment_Write(t *testing.T) {
+	t.Parallel()
+	games := splitFile(t, testutil.BB3)
+	g := games[2]
+	buf := new(bytes.Buffer)
+	err := g.Write(buf, record.Header{Cabinet: "blue", Meta: map[string]string{"game": "3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := record.NewReader(buf)
+	n := 0
+	for {
+		_, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	if n != len(g.Events) {
+		t.Errorf("wrong number of events, got %d want %d", n, len(g.Events))
+	}
+	h := r.Header()
+	want := map[string]string{
+		"game":          "3",
+		"map":           "map_night",
+		"orientation":   "BLUE_ON_LEFT",
+		"winner":        "Blue",
+		"win_condition": "economic",
+		"duration":      (128245700 * time.Microsecond).String(),
+		"players":       ",,,,,,,,,",
+	}
+	for k, v := range want {
+		if h.Meta[k] != v {
+			t.Errorf("wrong %s, got %s want %s", k, h.Meta[k], v)
+		}
+	}
+	if h.Cabinet != "blue" {
+		t.Errorf("wrong Cabinet, got %s want %s", h.Cabinet, "blue")
+	}
+}
+
+func splitFile(t *testing.T, path string) []Segment {
+	t.Helper()
+	games, err := SplitFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return games
+}
+
+func TestSplitter_Pending(t *testing.T) {
+	t.Parallel()
+	lines := []struct {
+		line    string
+		pending int
+	}{
+		{"![k[alive],v[8:00:00 PM]]!", 0},
+		{"![k[playernames],v[,,,,,,,,,]]!", 1},
+		{"![k[spawn],v[1,False]]!", 2},
+		{"![k[gamestart],v[map_day,False,0,False]]!", 3},
+		{"![k[playerKill],v[750,861,1,2,Queen]]!", 4},
+		{"![k[victory],v[Gold,military]]!", 0},
+		{"![k[alive],v[8:00:05 PM]]!", 0},
+	}
+	s := new(Splitter)
+	for _, l := range lines {
+		ev, err := event.Parse(l.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.Add(record.Envelope{Line: l.line, Event: ev})
+		if got := s.Pending(); got != l.pending {
+			t.Errorf("%s: got %d pending want %d", l.line, got, l.pending)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package game
+
+import (
+	"time"
+
+	"github.com/rickyninja/kqstat/event"
+)
+
+// PlayerStats are a player's totals for a game.
+type PlayerStats struct {
+	// Bee is the player's position on the cabs.
+	Bee event.Bee
+	// Name is the player's name from the playernames event, and is often empty.
+	Name string
+	// Kills is how many players this player killed.
+	Kills int
+	// Deaths is how many times this player was killed.
+	Deaths int
+	// QueenKills is how many times this player killed a queen.
+	QueenKills int
+	// Berries is how many berries this player deposited in their hive.
+	Berries int
+	// KickIns is how many berries this player kicked into a hive.
+	KickIns int
+	// SnailDistance is how far this player rode the snail, in pixels.
+	SnailDistance int
+}
+
+// gate is the position of a gate.
+type gate struct {
+	X, Y int
+}
+
+// Stats is the box score of a game, accumulated from its events.
+type Stats struct {
+	// Map is which map the game was played on.
+	Map event.Map
+	// Orientation is how the cabs are positioned next to each other.
+	Orientation event.CabOrientation
+	// Duration is how long the game lasted.
+	Duration time.Duration
+	// Victory is how the game was won, and is the zero Victory until it's over.
+	Victory event.Victory
+	// Players are the totals of each player, indexed by their Bee minus one.
+	Players [10]PlayerStats
+	// gates are the team in control of each gate that has been tagged.
+	gates map[gate]event.Team
+	// riders are the x coordinate each snail rider was last seen at.
+	riders map[event.Bee]int
+}
+
+// NewStats returns an empty *Stats.
+func NewStats() *Stats {
+	s := &Stats{
+		gates:  make(map[gate]event.Team),
+		riders: make(map[event.Bee]int),
+	}
+	for i := range s.Players {
+		s.Players[i].Bee = event.Bee(i + 1)
+	}
+	return s
+}
+
+// Summarize returns the box score of a game.
+func Summarize(seg Segment) *Stats {
+	s := NewStats()
+	for _, env := range seg.Events {
+		s.Add(env.Event)
+	}
+	return s
+}
+
+// Add updates the totals with the next event of the game.
+func (s *Stats) Add(ev event.Event) {
+	switch v := ev.(type) {
+	case event.PlayerNames:
+		for i := range s.Players {
+			if i < len(v) {
+				s.Players[i].Name = v[i]
+			}
+		}
+	case event.GameStart:
+		s.Map = v.Map
+		s.Orientation = v.Orientation
+	case event.GameEnd:
+		s.Map = v.Map
+		s.Orientation = v.Orientation
+		s.Duration = v.Duration
+	case event.Victory:
+		s.Victory = v
+	case event.PlayerKill:
+		if p := s.player(v.Slayer); p != nil {
+			p.Kills++
+			if v.SlainClass == event.Queen {
+				p.QueenKills++
+			}
+		}
+		if p := s.player(v.Slain); p != nil {
+			p.Deaths++
+		}
+		// Riders killed on the snail don't get a getOffSnail event.
+		s.ride(v.Slain, v.X, false)
+	case event.BerryDeposit:
+		if p := s.player(v.Who); p != nil {
+			p.Berries++
+		}
+	case event.BerryKickIn:
+		if p := s.player(v.Who); p != nil {
+			p.KickIns++
+		}
+	case event.BlessMaiden:
+		s.gates[gate{v.X, v.Y}] = v.Owner()
+	case event.GetOnSnail:
+		s.riders[v.Who] = v.X
+	case event.SnailEat:
+		s.ride(v.Rider, v.X, true)
+	case event.GetOffSnail:
+		s.ride(v.Who, v.X, false)
+	}
+}
+
+// ride credits a snail rider with the distance travelled since they were last seen, if they're riding.
+func (s *Stats) ride(b event.Bee, x int, stillRiding bool) {
+	from, ok := s.riders[b]
+	if !ok {
+		return
+	}
+	if p := s.player(b); p != nil {
+		p.SnailDistance += abs(x - from)
+	}
+	if stillRiding {
+		s.riders[b] = x
+	} else {
+		delete(s.riders, b)
+	}
+}
+
+// player returns the totals for a player position, or nil for an unknown position.
+func (s *Stats) player(b event.Bee) *PlayerStats {
+	if !b.Valid() {
+		return nil
+	}
+	return &s.Players[b-1]
+}
+
+// Team returns the totals of each player on a team, with the queen first.
+func (s *Stats) Team(t event.Team) []PlayerStats {
+	var players []PlayerStats
+	for _, p := range s.Players {
+		if p.Bee.Team() == t {
+			players = append(players, p)
+		}
+	}
+	return players
+}
+
+// QueenDeaths returns how many times a team's queen was killed.
+func (s *Stats) QueenDeaths(t event.Team) int {
+	switch t {
+	case event.Gold:
+		return s.Players[event.GoldQueen-1].Deaths
+	case event.Blue:
+		return s.Players[event.BlueQueen-1].Deaths
+	}
+	return 0
+}
+
+// Gates returns how many gates a team controls.
+func (s *Stats) Gates(t event.Team) int {
+	n := 0
+	for _, owner := range s.gates {
+		if owner == t {
+			n++
+		}
+	}
+	return n
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
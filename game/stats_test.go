@@ -0,0 +1,98 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/internal/testutil"
+)
+
+func TestSummarize(t *testing.T) {
+	t.Parallel()
+	games := splitFile(t, testutil.BB3)
+	s := Summarize(games[2])
+	if s.Map != event.Night {
+		t.Errorf("wrong Map, got %s want %s", s.Map, event.Night)
+	}
+	if s.Victory.Team != event.Blue || s.Victory.Type != event.Economic {
+		t.Errorf("wrong Victory, got %#v", s.Victory)
+	}
+	want := []struct {
+		kills, deaths, queenKills, berries, snail int
+	}{
+		{9, 0, 1, 0, 0},
+		{4, 1, 0, 0, 0},
+		{0, 3, 0, 1, 0},
+		{2, 3, 0, 1, 11},
+		{0, 3, 0, 3, 558},
+		{3, 4, 0, 2, 0},
+		{4, 1, 0, 0, 0},
+		{0, 4, 0, 4, 0},
+		{1, 5, 0, 0, 7},
+		{3, 2, 0, 5, 47},
+	}
+	for i, w := range want {
+		p := s.Players[i]
+		if p.Bee != event.Bee(i+1) {
+			t.Errorf("wrong Bee, got %s want %s", p.Bee, event.Bee(i+1))
+		}
+		if p.Kills != w.kills {
+			t.Errorf("%s: wrong Kills, got %d want %d", p.Bee, p.Kills, w.kills)
+		}
+		if p.Deaths != w.deaths {
+			t.Errorf("%s: wrong Deaths, got %d want %d", p.Bee, p.Deaths, w.deaths)
+		}
+		if p.QueenKills != w.queenKills {
+			t.Errorf("%s: wrong QueenKills, got %d want %d", p.Bee, p.QueenKills, w.queenKills)
+		}
+		if p.Berries != w.berries {
+			t.Errorf("%s: wrong Berries, got %d want %d", p.Bee, p.Berries, w.berries)
+		}
+		if p.SnailDistance != w.snail {
+			t.Errorf("%s: wrong SnailDistance, got %d want %d", p.Bee, p.SnailDistance, w.snail)
+		}
+	}
+	if got := s.QueenDeaths(event.Blue); got != 1 {
+		t.Errorf("wrong blue QueenDeaths, got %d want %d", got, 1)
+	}
+	if got := s.Gates(event.Blue); got != 1 {
+		t.Errorf("wrong blue Gates, got %d want %d", got, 1)
+	}
+	if got := s.Gates(event.Gold); got != 4 {
+		t.Errorf("wrong gold Gates, got %d want %d", got, 4)
+	}
+	berries := 0
+	for _, p := range s.Team(event.Blue) {
+		berries += p.Berries
+	}
+	// An economic victory takes 12 berries.
+	if berries != 12 {
+		t.Errorf("wrong blue berries, got %d want %d", berries, 12)
+	}
+}
+
+func TestStats_GoldGates(t *testing.T) {
+	t.Parallel()
+	// The cabinet sends gold's gates as Red, and gold tagged gates in every game of the log.
+	for i, g := range splitFile(t, testutil.BB3) {
+		s := Summarize(g)
+		if got := s.Gates(event.Gold); got == 0 {
+			t.Errorf("game %d: got %d gold Gates, want some", i, got)
+		}
+		if got := s.Gates(event.Red); got != 0 {
+			t.Errorf("game %d: got %d Red Gates want 0", i, got)
+		}
+	}
+}
+
+func TestStats_SnailRiderKilled(t *testing.T) {
+	t.Parallel()
+	s := NewStats()
+	s.Add(event.GetOnSnail{X: 900, Y: 11, Who: event.GoldStripes})
+	s.Add(event.SnailEat{X: 950, Y: 11, Rider: event.GoldStripes, Meal: event.BlueAbs})
+	s.Add(event.PlayerKill{X: 980, Y: 20, Slayer: event.BlueQueen, Slain: event.GoldStripes, SlainClass: event.Worker})
+	s.Add(event.GetOffSnail{X: 1200, Y: 11, Who: event.GoldStripes})
+	if got := s.Players[event.GoldStripes-1].SnailDistance; got != 80 {
+		t.Errorf("wrong SnailDistance, got %d want %d", got, 80)
+	}
+}
@@ -0,0 +1,147 @@
+package heatmap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/internal/testutil"
+	"github.com/rickyninja/kqstat/record"
+)
+
+func games(t *testing.T) []game.Segment {
+	t.Helper()
+	fd, err := os.Open(testutil.BB3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	segs, err := game.Split(record.NewReader(fd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return segs
+}
+
+func TestPoints(t *testing.T) {
+	var points []Point
+	for _, seg := range games(t) {
+		points = append(points, Points(seg)...)
+	}
+	deposits := Select(points, Filter{Keys: []string{"berryDeposit"}})
+	if len(deposits) == 0 {
+		t.Fatal("no berry deposits")
+	}
+	// Every game in the recording is BLUE_ON_LEFT, so each team's hive is on its own side.
+	for _, p := range deposits {
+		if p.Team == event.Blue && p.X > Width/2 || p.Team == event.Gold && p.X < Width/2 {
+			t.Errorf("%s deposit on the wrong side at %d", p.Team, p.X)
+		}
+	}
+	snail := Select(points, Filter{Keys: []string{"getOnSnail"}})
+	if len(snail) == 0 || snail[0].Key != "getOnSnail" {
+		t.Fatalf("got snail points %v", snail)
+	}
+	queen := Select(points, Filter{Player: "Gold-Queen", Keys: []string{"playerKill"}})
+	for _, p := range queen {
+		if p.Who != event.GoldQueen || p.Team != event.Gold {
+			t.Errorf("got %+v for gold queen kills", p)
+		}
+	}
+	if len(queen) == 0 {
+		t.Error("gold queen never got a kill")
+	}
+	for _, p := range Select(points, Filter{Map: event.Night, Team: event.Blue}) {
+		if p.Map != event.Night || p.Team != event.Blue {
+			t.Errorf("filter matched %+v", p)
+		}
+	}
+	gates := Select(points, Filter{Keys: []string{"blessMaiden"}})
+	if len(gates) == 0 || gates[0].Who != 0 {
+		t.Errorf("got gate points %v", gates)
+	}
+	// The cabinet sends gold's gates as Red.
+	if gold := Select(points, Filter{Keys: []string{"blessMaiden"}, Team: event.Gold}); len(gold) == 0 {
+		t.Error("got no gold gate points")
+	}
+}
+
+func TestPoints_Mirrored(t *testing.T) {
+	seg := game.Segment{
+		Names: event.PlayerNames{"Kim"},
+		Start: event.GameStart{Map: event.Day, Orientation: event.GoldOnLeft},
+		Events: []record.Envelope{
+			{Event: event.PlayerKill{X: 300, Y: 200, Slayer: event.GoldQueen, Slain: event.BlueQueen, SlainClass: event.Queen}},
+			{Event: event.Alive{}},
+		},
+	}
+	points := Points(seg)
+	if len(points) != 1 {
+		t.Fatalf("got %d points want 1", len(points))
+	}
+	p := points[0]
+	if p.X != Width-300 || p.Y != 200 || p.Name != "Kim" || p.Map != event.Day || p.Key != "playerKill" {
+		t.Errorf("got %+v", p)
+	}
+}
+
+func TestHeatmap(t *testing.T) {
+	points := []Point{{X: 960, Y: 540}, {X: 960, Y: 540}, {X: 100, Y: 100}}
+	bg := image.NewUniform(color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+	img := Heatmap(points, Options{Width: 192, Height: 108, Background: image.NewRGBA(image.Rect(0, 0, 1, 1))})
+	if img.Bounds() != image.Rect(0, 0, 192, 108) {
+		t.Fatalf("got bounds %v", img.Bounds())
+	}
+	hot, cold := img.RGBAAt(96, 54), img.RGBAAt(10, 97)
+	if hot.R < 200 || hot.G > 50 {
+		t.Errorf("got hottest pixel %v want red", hot)
+	}
+	if cold.A == 0 || cold.R >= hot.R {
+		t.Errorf("got single event pixel %v want cooler than %v", cold, hot)
+	}
+	if empty := img.RGBAAt(190, 2); empty.A != 0 {
+		t.Errorf("got empty pixel %v want transparent", empty)
+	}
+	img = Scatter(points, Options{Width: 192, Height: 108, Background: bg})
+	if c := img.RGBAAt(190, 2); c != (color.RGBA{10, 20, 30, 255}) {
+		t.Errorf("got background pixel %v", c)
+	}
+}
+
+func TestSVG(t *testing.T) {
+	var points []Point
+	for _, seg := range games(t) {
+		points = append(points, Points(seg)...)
+	}
+	kills := Select(points, Filter{Keys: []string{"playerKill"}})
+	for name, write := range map[string]func(*bytes.Buffer) error{
+		"heatmap": func(b *bytes.Buffer) error { return HeatmapSVG(b, kills, Options{BackgroundHref: "day.png?a&b"}) },
+		"scatter": func(b *bytes.Buffer) error { return ScatterSVG(b, kills, Options{}) },
+	} {
+		var buf bytes.Buffer
+		err := write(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var doc struct {
+			XMLName xml.Name
+			Width   int `xml:"width,attr"`
+		}
+		err = xml.Unmarshal(buf.Bytes(), &doc)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if doc.XMLName.Local != "svg" || doc.Width != Width/2 {
+			t.Errorf("%s: got %+v", name, doc)
+		}
+		if name == "scatter" && strings.Count(buf.String(), "<circle") != len(kills) {
+			t.Errorf("got %d circles want %d", strings.Count(buf.String(), "<circle"), len(kills))
+		}
+	}
+}
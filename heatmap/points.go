@@ -0,0 +1,136 @@
+// Package heatmap renders the positions of events as heatmaps and scatter plots, in PNG or SVG.
+//
+// Positions are in the stats service's coordinate system, which is 1920 by 1080 with y increasing upward,
+// so the snail track is near y=0.  When the cabs are GOLD_ON_LEFT the whole map is mirrored, so positions
+// from those games are mirrored back, and every plot is drawn as though blue were on the left.
+package heatmap
+
+import (
+	"strings"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/game"
+)
+
+// Width and Height are the size of a map in the stats service's coordinate system.
+const (
+	Width  = 1920
+	Height = 1080
+)
+
+// Point is the position of an event.
+type Point struct {
+	// X and Y are the event's position, mirrored so that blue is on the left.
+	X, Y int
+	// Key is the event's key, such as playerKill.
+	Key string
+	// Map is the map the game was played on.
+	Map event.Map
+	// Team is the team of the player responsible for the event, or of the gate for blessMaiden.
+	Team event.Team
+	// Who is the player responsible for the event, such as the slayer of a playerKill, and is 0 for blessMaiden.
+	Who event.Bee
+	// Name is the name of the player in Who's position, and is often empty.
+	Name string
+}
+
+// Points returns the position of every event of a game that has one.
+func Points(seg game.Segment) []Point {
+	mirror := seg.Start.Orientation == event.GoldOnLeft
+	var points []Point
+	for _, env := range seg.Events {
+		p, ok := position(env.Event)
+		if !ok {
+			continue
+		}
+		if mirror {
+			p.X = Width - p.X
+		}
+		p.Map = seg.Start.Map
+		if p.Who != 0 {
+			p.Team = p.Who.Team()
+			if i := int(p.Who) - 1; i < len(seg.Names) {
+				p.Name = seg.Names[i]
+			}
+		}
+		points = append(points, p)
+	}
+	return points
+}
+
+// position returns the position of an event, if it has one.  Only X, Y, Key, Who and Team are set.
+func position(ev event.Event) (Point, bool) {
+	x, y, ok := event.Position(ev)
+	if !ok {
+		return Point{}, false
+	}
+	p := Point{X: x, Y: y, Key: strings.TrimSuffix(event.Key(ev), ": ")}
+	switch v := ev.(type) {
+	case event.PlayerKill:
+		p.Who = v.Slayer
+	case event.BlessMaiden:
+		p.Team = v.Owner()
+	case event.ReserveMaiden:
+		p.Who = v.Who
+	case event.UnreserveMaiden:
+		p.Who = v.Who
+	case event.UseMaiden:
+		p.Who = v.Who
+	case event.GetOnSnail:
+		p.Who = v.Who
+	case event.GetOffSnail:
+		p.Who = v.Who
+	case event.SnailEat:
+		p.Who = v.Rider
+	case event.SnailEscape:
+		p.Who = v.Who
+	case event.BerryDeposit:
+		p.Who = v.Who
+	case event.BerryKickIn:
+		p.Who = v.Who
+	}
+	return p, true
+}
+
+// Filter selects points.  Empty fields match every point.
+type Filter struct {
+	Map  event.Map
+	Team event.Team
+	// Player matches a player's name, or their position such as gold-queen.
+	Player string
+	// Keys are the event keys to match, such as playerKill.
+	Keys []string
+}
+
+// Match reports if p is selected by f.
+func (f Filter) Match(p Point) bool {
+	if f.Map != "" && p.Map != f.Map {
+		return false
+	}
+	if f.Team != "" && p.Team != f.Team {
+		return false
+	}
+	if f.Player != "" && !strings.EqualFold(p.Name, f.Player) && (p.Who == 0 || p.Who.String() != strings.ToLower(f.Player)) {
+		return false
+	}
+	if len(f.Keys) == 0 {
+		return true
+	}
+	for _, k := range f.Keys {
+		if strings.EqualFold(k, p.Key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Select returns the points selected by f.
+func Select(points []Point, f Filter) []Point {
+	var selected []Point
+	for _, p := range points {
+		if f.Match(p) {
+			selected = append(selected, p)
+		}
+	}
+	return selected
+}
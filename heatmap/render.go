@@ -0,0 +1,177 @@
+package heatmap
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/rickyninja/kqstat/event"
+)
+
+// Options control how plots are drawn.
+type Options struct {
+	// Width and Height are the size of the plot in pixels, and default to half the size of a map.
+	Width, Height int
+	// Radius is how far each event spreads on a heatmap, or the size of its dot on a scatter plot,
+	// in map pixels.  It defaults to 30.
+	Radius float64
+	// Background is drawn behind PNG plots, scaled to fit, when set.  It should be drawn with blue on the left.
+	Background image.Image
+	// BackgroundHref is the URL of an image drawn behind SVG plots, when set.
+	BackgroundHref string
+}
+
+// withDefaults returns o with defaults for its unset fields.
+func (o Options) withDefaults() Options {
+	if o.Width <= 0 || o.Height <= 0 {
+		o.Width, o.Height = Width/2, Height/2
+	}
+	if o.Radius <= 0 {
+		o.Radius = 30
+	}
+	return o
+}
+
+// scale returns the number of plot pixels per map pixel.
+func (o Options) scale() (float64, float64) {
+	return float64(o.Width) / Width, float64(o.Height) / Height
+}
+
+// plot converts a map position to plot coordinates, where y increases downward.
+func (o Options) plot(p Point) (float64, float64) {
+	sx, sy := o.scale()
+	return float64(p.X) * sx, float64(Height-p.Y) * sy
+}
+
+// Heatmap draws the density of points, from transparent through blue, green and yellow to red.
+func Heatmap(points []Point, o Options) *image.RGBA {
+	o = o.withDefaults()
+	img := canvas(o)
+	sx, _ := o.scale()
+	sigma := o.Radius * sx
+	reach := int(math.Ceil(3 * sigma))
+	density := make([]float64, o.Width*o.Height)
+	max := 0.0
+	for _, p := range points {
+		px, py := o.plot(p)
+		cx, cy := int(px), int(py)
+		for y := cy - reach; y <= cy+reach; y++ {
+			if y < 0 || y >= o.Height {
+				continue
+			}
+			for x := cx - reach; x <= cx+reach; x++ {
+				if x < 0 || x >= o.Width {
+					continue
+				}
+				dx, dy := float64(x)-px, float64(y)-py
+				i := y*o.Width + x
+				density[i] += math.Exp(-(dx*dx + dy*dy) / (2 * sigma * sigma))
+				if density[i] > max {
+					max = density[i]
+				}
+			}
+		}
+	}
+	if max == 0 {
+		return img
+	}
+	for y := 0; y < o.Height; y++ {
+		for x := 0; x < o.Width; x++ {
+			d := density[y*o.Width+x] / max
+			if d < 0.01 {
+				continue
+			}
+			over(img, x, y, ramp(d))
+		}
+	}
+	return img
+}
+
+// Scatter draws a dot for each point, colored by team.
+func Scatter(points []Point, o Options) *image.RGBA {
+	o = o.withDefaults()
+	img := canvas(o)
+	sx, _ := o.scale()
+	r := math.Max(1, o.Radius*sx/3)
+	for _, p := range points {
+		px, py := o.plot(p)
+		c := teamColor(p.Team)
+		for y := int(py - r); y <= int(py+r); y++ {
+			for x := int(px - r); x <= int(px+r); x++ {
+				dx, dy := float64(x)-px, float64(y)-py
+				if dx*dx+dy*dy <= r*r {
+					over(img, x, y, c)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// canvas returns a transparent image of the plot's size, with the background drawn on it.
+func canvas(o Options) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, o.Width, o.Height))
+	if o.Background == nil {
+		return img
+	}
+	// Nearest neighbor scaling is rough, but the background is only there for reference.
+	b := o.Background.Bounds()
+	for y := 0; y < o.Height; y++ {
+		for x := 0; x < o.Width; x++ {
+			img.Set(x, y, o.Background.At(b.Min.X+x*b.Dx()/o.Width, b.Min.Y+y*b.Dy()/o.Height))
+		}
+	}
+	return img
+}
+
+// over composites c over the pixel of img at x, y.
+func over(img *image.RGBA, x, y int, c color.NRGBA) {
+	if !(image.Point{x, y}.In(img.Rect)) {
+		return
+	}
+	i := img.PixOffset(x, y)
+	a := uint32(c.A)
+	for j, v := range []uint8{c.R, c.G, c.B} {
+		img.Pix[i+j] = uint8((uint32(v)*a + uint32(img.Pix[i+j])*(255-a)) / 255)
+	}
+	img.Pix[i+3] = uint8(a + uint32(img.Pix[i+3])*(255-a)/255)
+}
+
+// ramp returns the heatmap color of a density from 0 to 1.
+func ramp(d float64) color.NRGBA {
+	stops := []struct {
+		at      float64
+		r, g, b float64
+	}{
+		{0, 0, 0, 255},
+		{0.35, 0, 255, 0},
+		{0.7, 255, 255, 0},
+		{1, 255, 0, 0},
+	}
+	for i := 1; i < len(stops); i++ {
+		lo, hi := stops[i-1], stops[i]
+		if d > hi.at {
+			continue
+		}
+		f := (d - lo.at) / (hi.at - lo.at)
+		return color.NRGBA{
+			R: uint8(lo.r + f*(hi.r-lo.r)),
+			G: uint8(lo.g + f*(hi.g-lo.g)),
+			B: uint8(lo.b + f*(hi.b-lo.b)),
+			// Sparse areas fade out, so the background shows through.
+			A: uint8(math.Min(1, 0.3+d) * 200),
+		}
+	}
+	return color.NRGBA{R: 255, A: 200}
+}
+
+// teamColor returns the color of a team's dots.
+func teamColor(t event.Team) color.NRGBA {
+	switch t {
+	case event.Gold:
+		return color.NRGBA{R: 245, G: 184, B: 0, A: 220}
+	case event.Blue:
+		return color.NRGBA{R: 47, G: 124, B: 246, A: 220}
+	}
+	return color.NRGBA{R: 200, G: 200, B: 200, A: 220}
+}
@@ -0,0 +1,80 @@
+package heatmap
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+)
+
+// HeatmapSVG writes the density of points as SVG.  Points are counted in square cells as wide as o.Radius,
+// and the cells are blurred together.
+func HeatmapSVG(w io.Writer, points []Point, o Options) error {
+	o = o.withDefaults()
+	cols, rows := int(math.Ceil(Width/o.Radius)), int(math.Ceil(Height/o.Radius))
+	counts := make([]int, cols*rows)
+	max := 0
+	for _, p := range points {
+		col, row := int(float64(p.X)/o.Radius), int(float64(Height-p.Y)/o.Radius)
+		if col < 0 || col >= cols || row < 0 || row >= rows {
+			continue
+		}
+		i := row*cols + col
+		counts[i]++
+		if counts[i] > max {
+			max = counts[i]
+		}
+	}
+	sx, sy := o.scale()
+	cw, ch := o.Radius*sx, o.Radius*sy
+	bw := bufio.NewWriter(w)
+	openSVG(bw, o)
+	fmt.Fprintf(bw, `<filter id="blur"><feGaussianBlur stdDeviation="%.1f"/></filter>`+"\n", cw/2)
+	fmt.Fprintln(bw, `<g filter="url(#blur)">`)
+	for i, n := range counts {
+		if n == 0 {
+			continue
+		}
+		c := ramp(float64(n) / float64(max))
+		fmt.Fprintf(bw, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="rgb(%d,%d,%d)" fill-opacity="%.2f"/>`+"\n",
+			float64(i%cols)*cw, float64(i/cols)*ch, cw, ch, c.R, c.G, c.B, float64(c.A)/255)
+	}
+	fmt.Fprintln(bw, "</g>")
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// ScatterSVG writes a dot for each point as SVG, colored by team.  Each dot has a title describing its event.
+func ScatterSVG(w io.Writer, points []Point, o Options) error {
+	o = o.withDefaults()
+	sx, _ := o.scale()
+	r := math.Max(1, o.Radius*sx/3)
+	bw := bufio.NewWriter(w)
+	openSVG(bw, o)
+	for _, p := range points {
+		x, y := o.plot(p)
+		c := teamColor(p.Team)
+		title := p.Key
+		if p.Who != 0 {
+			title += " " + p.Who.String()
+		}
+		if p.Name != "" {
+			title += " " + p.Name
+		}
+		fmt.Fprintf(bw, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="rgb(%d,%d,%d)" fill-opacity="%.2f"><title>%s (%d, %d)</title></circle>`+"\n",
+			x, y, r, c.R, c.G, c.B, float64(c.A)/255, html.EscapeString(title), p.X, p.Y)
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// openSVG writes the opening svg element, and the background image if there is one.
+func openSVG(w io.Writer, o Options) {
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		o.Width, o.Height, o.Width, o.Height)
+	if o.BackgroundHref != "" {
+		fmt.Fprintf(w, `<image xlink:href="%s" x="0" y="0" width="%d" height="%d" preserveAspectRatio="none"/>`+"\n",
+			html.EscapeString(o.BackgroundHref), o.Width, o.Height)
+	}
+}
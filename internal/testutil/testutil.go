@@ -0,0 +1,39 @@
+// Package testutil has fixtures and helpers shared by the tests of kqstat's packages.
+package testutil
+
+import (
+	"net"
+	"net/http"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// BB3 is the path of the recording in testdata/bb3 of the blue cabinet, which has 17 games.
+var BB3 = filepath.Join(root(), "testdata", "bb3", "blue.logs-1540028330.51393.log")
+
+// root returns the directory of the repository, so BB3 can be found from the tests of any package.
+func root() string {
+	_, file, _, ok := runtime.Caller(0)
+	if !ok {
+		panic("testutil: can't find the repository from the caller's file")
+	}
+	return filepath.Join(filepath.Dir(file), "..", "..")
+}
+
+// NopLogger discards log output from connections that outlive a test.
+type NopLogger struct{}
+
+// Logf does nothing.
+func (NopLogger) Logf(format string, a ...interface{}) {}
+
+// Serve serves h on a random local port until the test binary exits, and returns its address.
+func Serve(t testing.TB, h http.Handler) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go http.Serve(l, h)
+	return l.Addr().String()
+}
@@ -0,0 +1,155 @@
+// Package metrics monitors live cabinets, and exposes their activity in the Prometheus text format.
+package metrics
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rickyninja/kqstat/event"
+)
+
+// Collector counts the activity of a single cabinet.  It's safe for concurrent use.
+type Collector struct {
+	// Cabinet is the name of the cabinet, used as the cabinet label of every metric.
+	Cabinet string
+	mu      sync.Mutex
+	events  map[string]uint64
+	// wins are indexed by team and then win condition.
+	wins        map[event.Team]map[event.WinCondition]uint64
+	games       uint64
+	parseErrors uint64
+	connected   bool
+	connects    uint64
+	lastAlive   time.Time
+	// aliveInterval is the time between the last two alive events.
+	aliveInterval time.Duration
+	// gaps are how many of the times between alive events were at most each of gapBuckets, and gapSum is their total.
+	gaps     [len(gapBuckets)]uint64
+	gapSum   time.Duration
+	gapCount uint64
+}
+
+// gapBuckets are the upper bounds of the histogram of time between alive events, in seconds.  The cabinet sends one
+// every 5 seconds, so the larger buckets count alive events held up on the way from the cabinet.
+var gapBuckets = [...]float64{4.5, 5.5, 6, 7.5, 10, 15, 30, 60}
+
+// NewCollector returns a *Collector for the named cabinet.
+func NewCollector(cabinet string) *Collector {
+	return &Collector{
+		Cabinet: cabinet,
+		events:  make(map[string]uint64),
+		wins:    make(map[event.Team]map[event.WinCondition]uint64),
+	}
+}
+
+// Event counts an event received at t.  ev and err are the result of parsing the event.
+func (c *Collector) Event(t time.Time, ev event.Event, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil || ev == nil {
+		c.parseErrors++
+		return
+	}
+	// Some keys end with a colon and space, which are noise in a label.
+	key := strings.TrimRight(event.Key(ev), ": ")
+	c.events[key]++
+	switch v := ev.(type) {
+	case event.Alive:
+		if !c.lastAlive.IsZero() {
+			c.aliveInterval = t.Sub(c.lastAlive)
+			c.addGap(c.aliveInterval)
+		}
+		c.lastAlive = t
+	case event.Victory:
+		c.games++
+		byType, ok := c.wins[v.Team]
+		if !ok {
+			byType = make(map[event.WinCondition]uint64)
+			c.wins[v.Team] = byType
+		}
+		byType[v.Type]++
+	}
+}
+
+// addGap adds the time between two alive events to the histogram.
+func (c *Collector) addGap(gap time.Duration) {
+	for i, le := range gapBuckets {
+		if gap.Seconds() <= le {
+			c.gaps[i]++
+		}
+	}
+	c.gapSum += gap
+	c.gapCount++
+}
+
+// Connected records that the connection to the cabinet was established.
+func (c *Collector) Connected() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connected = true
+	c.connects++
+	// Keep alive timing starts over with each connection.
+	c.lastAlive = time.Time{}
+}
+
+// Disconnected records that the connection to the cabinet was lost.
+func (c *Collector) Disconnected() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connected = false
+}
+
+// sample is a single value of a metric.
+type sample struct {
+	name string
+	// suffix follows name for the parts of a histogram, such as _bucket.
+	suffix string
+	labels []string
+	value  float64
+}
+
+// samples returns the current value of every metric, as of now.
+func (c *Collector) samples(now time.Time) []sample {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cab := []string{"cabinet", c.Cabinet}
+	var s []sample
+	for key, n := range c.events {
+		s = append(s, sample{"kqstat_events_total", "", append(cab, "key", key), float64(n)})
+	}
+	s = append(s, sample{"kqstat_games_total", "", cab, float64(c.games)})
+	for team, byType := range c.wins {
+		for cond, n := range byType {
+			s = append(s, sample{"kqstat_wins_total", "", append(cab, "team", string(team), "win_condition", string(cond)), float64(n)})
+		}
+	}
+	s = append(s, sample{"kqstat_parse_errors_total", "", cab, float64(c.parseErrors)})
+	up := 0.0
+	if c.connected {
+		up = 1
+	}
+	s = append(s, sample{"kqstat_connected", "", cab, up})
+	reconnects := 0.0
+	if c.connects > 1 {
+		reconnects = float64(c.connects - 1)
+	}
+	s = append(s, sample{"kqstat_reconnects_total", "", cab, reconnects})
+	if c.aliveInterval > 0 {
+		s = append(s, sample{"kqstat_keepalive_interval_seconds", "", cab, c.aliveInterval.Seconds()})
+	}
+	if c.connected && !c.lastAlive.IsZero() {
+		s = append(s, sample{"kqstat_keepalive_age_seconds", "", cab, now.Sub(c.lastAlive).Seconds()})
+	}
+	if c.gapCount > 0 {
+		const name = "kqstat_keepalive_gap_seconds"
+		for i, le := range gapBuckets {
+			s = append(s, sample{name, "_bucket", append(cab, "le", strconv.FormatFloat(le, 'g', -1, 64)), float64(c.gaps[i])})
+		}
+		s = append(s, sample{name, "_bucket", append(cab, "le", "+Inf"), float64(c.gapCount)})
+		s = append(s, sample{name, "_sum", cab, c.gapSum.Seconds()})
+		s = append(s, sample{name, "_count", cab, float64(c.gapCount)})
+	}
+	return s
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metric describes a metric for its HELP and TYPE lines.
+type metric struct {
+	name string
+	typ  string
+	help string
+}
+
+// metrics are every metric a Collector provides, in the order they're exposed.
+var metrics = []metric{
+	{"kqstat_connected", "gauge", "Whether the connection to the cabinet is up."},
+	{"kqstat_reconnects_total", "counter", "Connections to the cabinet made after the first."},
+	{"kqstat_events_total", "counter", "Events received, by key."},
+	{"kqstat_parse_errors_total", "counter", "Events that failed to parse."},
+	{"kqstat_games_total", "counter", "Games played to a victory."},
+	{"kqstat_wins_total", "counter", "Games won, by team and win condition."},
+	{"kqstat_keepalive_interval_seconds", "gauge", "Time between the last two alive events, which the cabinet sends every 5 seconds."},
+	{"kqstat_keepalive_age_seconds", "gauge", "Time since the last alive event."},
+	{"kqstat_keepalive_gap_seconds", "histogram", "Time between alive events as received, where gaps over 5 seconds are delays on the link from the cabinet."},
+}
+
+// Handler serves the metrics of a group of collectors in the Prometheus text format.
+type Handler struct {
+	mu         sync.Mutex
+	collectors []*Collector
+}
+
+// NewHandler returns a *Handler serving the metrics of collectors.
+func NewHandler(collectors ...*Collector) *Handler {
+	return &Handler{collectors: collectors}
+}
+
+// Add adds a collector to those served.
+func (h *Handler) Add(c *Collector) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.collectors = append(h.collectors, c)
+}
+
+// ServeHTTP does http.Handler.
+func (h *Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	now := time.Now()
+	h.mu.Lock()
+	byName := make(map[string][]sample)
+	for _, c := range h.collectors {
+		for _, s := range c.samples(now) {
+			byName[s.name] = append(byName[s.name], s)
+		}
+	}
+	h.mu.Unlock()
+
+	rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w := bufio.NewWriter(rw)
+	for _, m := range metrics {
+		samples := byName[m.name]
+		if len(samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", m.name, m.typ)
+		lines := make([]string, len(samples))
+		for i, s := range samples {
+			lines[i] = s.name + s.suffix + formatLabels(s.labels) + " " + strconv.FormatFloat(s.value, 'g', -1, 64)
+		}
+		// Collectors keep some samples in maps, so they're sorted for a stable order.  Histograms are already in
+		// order, with their buckets from smallest to largest.
+		if m.typ != "histogram" {
+			sort.Strings(lines)
+		}
+		for _, l := range lines {
+			fmt.Fprintln(w, l)
+		}
+	}
+	w.Flush()
+}
+
+// formatLabels formats label name and value pairs as {name="value",...}.
+func formatLabels(labels []string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i := 0; i+1 < len(labels); i += 2 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=\"%s\"", labels[i], labelEscaper.Replace(labels[i+1]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// labelEscaper escapes label values as required by the text format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
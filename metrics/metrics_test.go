@@ -0,0 +1,162 @@
+package metrics_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/internal/testutil"
+	"github.com/rickyninja/kqstat/metrics"
+	"github.com/rickyninja/kqstat/mock/kqstatd"
+)
+
+func TestHandler(t *testing.T) {
+	c := metrics.NewCollector(`blue "east"`)
+	c.Connected()
+	now := time.Now()
+	c.Event(now, event.Alive{}, nil)
+	c.Event(now.Add(5*time.Second), event.Alive{}, nil)
+	// The next alive was held up for 2 seconds.
+	c.Event(now.Add(12*time.Second), event.Alive{}, nil)
+	c.Event(now, event.GetOnSnail{Who: 3}, nil)
+	c.Event(now, event.Victory{Team: event.Gold, Type: event.Snail}, nil)
+	c.Event(now, nil, os.ErrInvalid)
+	srv := httptest.NewServer(metrics.NewHandler(c))
+	defer srv.Close()
+	body := scrape(t, srv.URL)
+	for _, want := range []string{
+		"# TYPE kqstat_events_total counter\n",
+		`kqstat_connected{cabinet="blue \"east\""} 1` + "\n",
+		`kqstat_reconnects_total{cabinet="blue \"east\""} 0` + "\n",
+		`kqstat_events_total{cabinet="blue \"east\"",key="alive"} 3` + "\n",
+		`kqstat_events_total{cabinet="blue \"east\"",key="getOnSnail"} 1` + "\n",
+		`kqstat_games_total{cabinet="blue \"east\""} 1` + "\n",
+		`kqstat_wins_total{cabinet="blue \"east\"",team="Gold",win_condition="snail"} 1` + "\n",
+		`kqstat_parse_errors_total{cabinet="blue \"east\""} 1` + "\n",
+		`kqstat_keepalive_interval_seconds{cabinet="blue \"east\""} 7` + "\n",
+		"kqstat_keepalive_age_seconds{",
+		"# TYPE kqstat_keepalive_gap_seconds histogram\n",
+		`kqstat_keepalive_gap_seconds_bucket{cabinet="blue \"east\"",le="4.5"} 0` + "\n" +
+			`kqstat_keepalive_gap_seconds_bucket{cabinet="blue \"east\"",le="5.5"} 1` + "\n",
+		`kqstat_keepalive_gap_seconds_bucket{cabinet="blue \"east\"",le="6"} 1` + "\n" +
+			`kqstat_keepalive_gap_seconds_bucket{cabinet="blue \"east\"",le="7.5"} 2` + "\n",
+		`kqstat_keepalive_gap_seconds_bucket{cabinet="blue \"east\"",le="+Inf"} 2` + "\n" +
+			`kqstat_keepalive_gap_seconds_sum{cabinet="blue \"east\""} 12` + "\n" +
+			`kqstat_keepalive_gap_seconds_count{cabinet="blue \"east\""} 2` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %q in:\n%s", want, body)
+		}
+	}
+}
+
+func TestMonitor_Replay(t *testing.T) {
+	fd, err := os.Open(testutil.BB3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	replay, err := kqstatd.NewReplay(fd, testutil.NopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := metrics.NewCollector("blue")
+	m := metrics.NewMonitor(testutil.Serve(t, replay), c, testutil.NopLogger{})
+	stop := make(chan struct{})
+	defer close(stop)
+	go m.Run(stop)
+	srv := httptest.NewServer(metrics.NewHandler(c))
+	defer srv.Close()
+	waitFor(t, srv.URL, []string{
+		`kqstat_connected{cabinet="blue"} 1`,
+		`kqstat_games_total{cabinet="blue"} `,
+		`kqstat_events_total{cabinet="blue",key="playerKill"} `,
+		`kqstat_wins_total{cabinet="blue",team="Blue",win_condition="military"} `,
+	})
+}
+
+func TestMonitor_Reconnect(t *testing.T) {
+	// Each connection gets two keep alives and a bad event, and is then closed.
+	sc := kqstatd.NewScenario(testutil.NopLogger{}).
+		SendEvent(0, "alive", "8:00:00 PM").
+		SendEvent(20*time.Millisecond, "alive", "8:00:00 PM").
+		Send(0, "not an event").
+		Expect(time.Second, "![k[im alive],v[]]!")
+	c := metrics.NewCollector("blue")
+	m := metrics.NewMonitor(testutil.Serve(t, sc), c, testutil.NopLogger{})
+	m.Retry = 10 * time.Millisecond
+	stop := make(chan struct{})
+	defer close(stop)
+	go m.Run(stop)
+	srv := httptest.NewServer(metrics.NewHandler(c))
+	defer srv.Close()
+	waitFor(t, srv.URL, []string{
+		`kqstat_reconnects_total{cabinet="blue"} `,
+		`kqstat_parse_errors_total{cabinet="blue"} `,
+		`kqstat_keepalive_interval_seconds{cabinet="blue"} `,
+		`kqstat_keepalive_gap_seconds_count{cabinet="blue"} `,
+	})
+	body := scrape(t, srv.URL)
+	if strings.Contains(body, `kqstat_reconnects_total{cabinet="blue"} 0`+"\n") {
+		t.Errorf("expected reconnects in:\n%s", body)
+	}
+}
+
+// waitFor scrapes url until every prefix starts a line with a non-zero value.
+func waitFor(t *testing.T, url string, prefixes []string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		body := scrape(t, url)
+		missing := ""
+		for _, p := range prefixes {
+			if !hasNonZero(body, p) {
+				missing = p
+				break
+			}
+		}
+		if missing == "" {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no non-zero %s in:\n%s", missing, body)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// hasNonZero reports if a line of body starts with prefix, and has a non-zero value.
+func hasNonZero(body, prefix string) bool {
+	for _, line := range strings.Split(body, "\n") {
+		if !strings.HasPrefix(line, prefix) {
+			continue
+		}
+		f := strings.Fields(line)
+		if f[len(f)-1] != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+func scrape(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := http.Get(url + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("got content type %s", ct)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
@@ -0,0 +1,80 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/rickyninja/kqstat"
+)
+
+// Logger is used to log connection problems.
+type Logger interface {
+	Logf(format string, a ...interface{})
+}
+
+// Monitor keeps a connection to a cabinet's stats service, and counts its events with a Collector.
+type Monitor struct {
+	// Addr is the host and port of the stats service.
+	Addr string
+	// Collector counts the cabinet's events.
+	Collector *Collector
+	// Retry is how long to wait before reconnecting after the connection is lost.
+	Retry time.Duration
+	log   Logger
+}
+
+// NewMonitor returns a *Monitor of the stats service at addr, counting its events with c.
+func NewMonitor(addr string, c *Collector, l Logger) *Monitor {
+	return &Monitor{
+		Addr:      addr,
+		Collector: c,
+		Retry:     5 * time.Second,
+		log:       l,
+	}
+}
+
+// Run connects to the stats service and counts its events, reconnecting whenever the connection is lost,
+// until stop is closed.
+func (m *Monitor) Run(stop <-chan struct{}) {
+	for {
+		m.session(stop)
+		select {
+		case <-stop:
+			return
+		case <-time.After(m.Retry):
+		}
+	}
+}
+
+// session counts events from a single connection to the stats service, until it's lost or stop is closed.
+func (m *Monitor) session(stop <-chan struct{}) {
+	cl, err := kqstat.NewClient(m.Addr, m.log)
+	if err != nil {
+		m.log.Logf("%s: failed to connect to %s: %s", m.Collector.Cabinet, m.Addr, err)
+		return
+	}
+	m.Collector.Connected()
+	defer m.Collector.Disconnected()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+			cl.Close()
+			cl.Conn.Close()
+		case <-done:
+		}
+	}()
+	for {
+		line, ev, err := cl.GetRawEvent()
+		if line == "" {
+			select {
+			case <-stop:
+			default:
+				m.log.Logf("%s: lost connection to %s: %s", m.Collector.Cabinet, m.Addr, err)
+			}
+			cl.Conn.Close()
+			return
+		}
+		m.Collector.Event(time.Now(), ev, err)
+	}
+}
@@ -0,0 +1,205 @@
+package kqstatd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Scenario is a scripted sequence of events sent to a client, along with the messages the client is expected to send back.
+// Each connection served runs the whole script once, and its outcome is published on Results.
+type Scenario struct {
+	steps   []step
+	results chan Result
+	log     Logger
+	// now is the clock read for the time of alive events.
+	now func() time.Time
+}
+
+// step is a single instruction in a Scenario.
+type step struct {
+	// delay is how long to wait after the previous step before sending line, or how long to wait for expect.
+	delay time.Duration
+	// line is sent to the client when set.
+	line string
+	// alive sends an alive event with the time it's sent, instead of line.
+	alive bool
+	// expect is the message required from the client when set.
+	expect string
+}
+
+// Result is the outcome of running a Scenario against a client.
+type Result struct {
+	// Failures describes each expectation the client did not meet.
+	Failures []string
+	// Received is every message sent by the client, in order.
+	Received []string
+}
+
+// Passed reports if the client met every expectation.
+func (r Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+// Err returns an error describing the failures, or nil if the client met every expectation.
+func (r Result) Err() error {
+	if r.Passed() {
+		return nil
+	}
+	return errors.New(strings.Join(r.Failures, "; "))
+}
+
+// NewScenario constructs an empty *Scenario.  Use the builder methods to add steps.
+func NewScenario(l Logger) *Scenario {
+	return &Scenario{
+		results: make(chan Result, 16),
+		log:     l,
+		now:     time.Now,
+	}
+}
+
+// Line formats an event key and its values as text sent by the stats service.
+func Line(key string, vals ...interface{}) string {
+	s := make([]string, len(vals))
+	for i, v := range vals {
+		s[i] = fmt.Sprint(v)
+	}
+	return fmt.Sprintf("![k[%s],v[%s]]!", key, strings.Join(s, ","))
+}
+
+// Send queues line to be sent to the client after delay has elapsed since the previous step.
+func (s *Scenario) Send(delay time.Duration, line string) *Scenario {
+	s.steps = append(s.steps, step{delay: delay, line: line})
+	return s
+}
+
+// SendEvent is like Send, but builds the line from an event key and its values.
+func (s *Scenario) SendEvent(delay time.Duration, key string, vals ...interface{}) *Scenario {
+	return s.Send(delay, Line(key, vals...))
+}
+
+// Expect requires the client to send msg within the given duration of the previous step.
+// Messages the client sent earlier, but which haven't been matched by a prior Expect, also count.
+func (s *Scenario) Expect(within time.Duration, msg string) *Scenario {
+	s.steps = append(s.steps, step{delay: within, expect: msg})
+	return s
+}
+
+// KeepAlive sends an alive event after delay, and expects the client's reply within the given duration.
+func (s *Scenario) KeepAlive(delay, within time.Duration) *Scenario {
+	s.steps = append(s.steps, step{delay: delay, alive: true})
+	return s.Expect(within, keepAliveMsg)
+}
+
+// Clock sets the clock read for the time of alive events when they're sent, which is time.Now by default.
+func (s *Scenario) Clock(now func() time.Time) *Scenario {
+	s.now = now
+	return s
+}
+
+// Results returns a channel receiving the Result of each connection served.
+func (s *Scenario) Results() <-chan Result {
+	return s.results
+}
+
+// ServeHTTP does http.Handler.
+func (s *Scenario) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	var upgrader = websocket.Upgrader{} // use default options
+	ws, err := upgrader.Upgrade(rw, req, nil)
+	if err != nil {
+		s.log.Logf("websocket Upgrade: %s", err)
+		return
+	}
+	con := newConn(ws, s.log)
+	defer con.Close()
+	res := s.run(con)
+	select {
+	case s.results <- res:
+	default:
+		s.log.Logf("Results channel is full, dropping scenario result.")
+	}
+}
+
+// run executes each step of the scenario against con.
+func (s *Scenario) run(con *conn) Result {
+	res := Result{}
+	done := make(chan struct{})
+	defer close(done)
+	messages := make(chan string)
+	go func() {
+		for {
+			_, message, err := con.ReadMessage()
+			if err != nil {
+				return
+			}
+			select {
+			case messages <- string(message):
+			case <-done:
+				return
+			}
+		}
+	}()
+	var pending []string
+	receive := func(m string) {
+		res.Received = append(res.Received, m)
+		pending = append(pending, m)
+	}
+	for _, st := range s.steps {
+		timer := time.NewTimer(st.delay)
+		if st.expect == "" {
+			line := st.line
+		wait:
+			for {
+				select {
+				case m := <-messages:
+					receive(m)
+				case <-timer.C:
+					break wait
+				}
+			}
+			if st.alive {
+				line = Line("alive", s.now().Format(DefaultKeepAlive.Format))
+			}
+			err := con.WriteMessage(websocket.TextMessage, []byte(line))
+			if err != nil {
+				res.Failures = append(res.Failures, fmt.Sprintf("failed to send %s: %s", line, err))
+				return res
+			}
+			continue
+		}
+	expect:
+		for {
+			if i := indexOf(pending, st.expect); i >= 0 {
+				pending = append(pending[:i], pending[i+1:]...)
+				break
+			}
+			select {
+			case m := <-messages:
+				receive(m)
+			case <-timer.C:
+				res.Failures = append(res.Failures, fmt.Sprintf("did not receive %s within %s", st.expect, st.delay))
+				break expect
+			}
+		}
+		timer.Stop()
+	}
+	err := con.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	if err != nil {
+		s.log.Logf("websocket WriteMessage: %s", err)
+	}
+	return res
+}
+
+// indexOf returns the index of the first element of list equal to s, or -1.
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
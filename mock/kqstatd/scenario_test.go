@@ -0,0 +1,120 @@
+package kqstatd_test
+
+import (
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rickyninja/kqstat"
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/internal/testutil"
+	"github.com/rickyninja/kqstat/mock/kqstatd"
+)
+
+func TestScenario_QueenDiesThreeTimes(t *testing.T) {
+	t.Parallel()
+	sc := kqstatd.NewScenario(t).
+		SendEvent(0, "gamestart", "map_day", "False", 0, "False").
+		KeepAlive(10*time.Millisecond, time.Second)
+	for i := 0; i < 3; i++ {
+		sc.SendEvent(10*time.Millisecond, "playerKill", 750, 861, 2, 1, "Queen")
+	}
+	sc.SendEvent(0, "victory", "Blue", "military")
+	addr := testutil.Serve(t, sc)
+	cl, err := kqstat.NewClient(addr, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kills := 0
+	for {
+		ev, err := cl.GetEvent()
+		if err != nil {
+			break
+		}
+		if v, ok := ev.(event.PlayerKill); ok && v.Slain == event.GoldQueen {
+			kills++
+		}
+	}
+	if kills != 3 {
+		t.Errorf("wrong gold queen deaths, got %d want %d", kills, 3)
+	}
+	res := <-sc.Results()
+	if err := res.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestScenario_MissingReply(t *testing.T) {
+	t.Parallel()
+	sc := kqstatd.NewScenario(t).
+		KeepAlive(0, 50*time.Millisecond).
+		Expect(50*time.Millisecond, "![k[im alive],v[]]!")
+	addr := testutil.Serve(t, sc)
+	u := url.URL{Scheme: "ws", Host: addr}
+	ws, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	// Reply to the only keep alive, so the second expectation fails.
+	if _, _, err := ws.ReadMessage(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ws.WriteMessage(websocket.TextMessage, []byte("![k[im alive],v[]]!")); err != nil {
+		t.Fatal(err)
+	}
+	res := <-sc.Results()
+	if res.Passed() {
+		t.Fatal("expected scenario to fail")
+	}
+	if len(res.Failures) != 1 {
+		t.Errorf("wrong number of failures, got %d want %d: %v", len(res.Failures), 1, res.Failures)
+	}
+	if len(res.Received) != 1 {
+		t.Errorf("wrong number of received messages, got %d want %d", len(res.Received), 1)
+	}
+}
+
+func TestScenario_KeepAliveTime(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2018, 10, 20, 23, 59, 59, 0, time.UTC)
+	var reads int32
+	sc := kqstatd.NewScenario(t).KeepAlive(0, time.Second).Clock(func() time.Time {
+		atomic.AddInt32(&reads, 1)
+		return now
+	})
+	// The alive event has the time it was sent, not when the scenario was built.
+	if n := atomic.LoadInt32(&reads); n != 0 {
+		t.Fatalf("clock read %d times while building the scenario", n)
+	}
+	addr := testutil.Serve(t, sc)
+	cl, err := kqstat.NewClient(addr, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, err := cl.GetEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := event.Alive{Time: "11:59:59 PM"}
+	if ev != want {
+		t.Errorf("got %#v want %#v", ev, want)
+	}
+	if n := atomic.LoadInt32(&reads); n != 1 {
+		t.Errorf("clock read %d times want 1", n)
+	}
+	if err := (<-sc.Results()).Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLine(t *testing.T) {
+	t.Parallel()
+	got := kqstatd.Line("playerKill", 1301, 1014, 1, 10, "Soldier")
+	want := "![k[playerKill],v[1301,1014,1,10,Soldier]]!"
+	if got != want {
+		t.Errorf("wrong Line, got %s want %s", got, want)
+	}
+}
@@ -20,8 +20,31 @@ type Logger interface {
 
 // Replay replays stats from an io.Reader on repeat.
 type Replay struct {
-	buffer []byte
-	log    Logger
+	// KeepAlive controls the keep alive events sent to each connection.
+	// It defaults to DefaultKeepAlive, and may be changed before serving any connections.
+	KeepAlive KeepAlive
+	buffer    []byte
+	log       Logger
+}
+
+// KeepAlive describes how the stats service checks that its peer is still connected.
+type KeepAlive struct {
+	// Interval is how often an alive event is sent.  Keep alives are disabled when it isn't positive.
+	Interval time.Duration
+	// Format is the time layout used for the value of alive events.
+	Format string
+	// Grace is how long the peer has to reply to an alive event.  Interval is used when it isn't positive.
+	Grace time.Duration
+	// MaxMissed is how many consecutive alive events may go without a reply before the connection is closed.
+	MaxMissed int
+}
+
+// DefaultKeepAlive behaves like a real cabinet, which sends an alive event every 5 seconds.
+var DefaultKeepAlive = KeepAlive{
+	Interval:  5 * time.Second,
+	Format:    "3:04:05 PM",
+	Grace:     5 * time.Second,
+	MaxMissed: 2,
 }
 
 // NewReplay constructs a *Replay object using an io.Reader as its input source.
@@ -31,8 +54,9 @@ func NewReplay(r io.Reader, l Logger) (*Replay, error) {
 		return nil, err
 	}
 	re := &Replay{
-		buffer: buf,
-		log:    l,
+		KeepAlive: DefaultKeepAlive,
+		buffer:    buf,
+		log:       l,
 	}
 	return re, nil
 }
@@ -48,13 +72,25 @@ func (r *Replay) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	con := newConn(ws, r.log)
 	defer con.Close()
 	done := make(chan struct{})
-	defer func() { done <- struct{}{} }()
-	go con.doKeepAlives(done)
+	defer close(done)
+	replies := make(chan struct{}, 1)
+	go con.readReplies(replies, done)
+	go con.doKeepAlives(r.KeepAlive, replies, done)
+	if len(r.buffer) == 0 {
+		// Nothing to replay, so only keep alives are sent until the peer goes away.
+		select {
+		case <-con.failedKeepAlive:
+		case <-con.gone:
+		}
+		return
+	}
 	reader := r.getReader()
 	for {
 		select {
 		case <-con.failedKeepAlive:
 			return
+		case <-con.gone:
+			return
 		default:
 			line, err := reader.ReadBytes('\n')
 			if err != nil {
@@ -91,6 +127,7 @@ func (r *Replay) getReader() *bufio.Reader {
 type conn struct {
 	*websocket.Conn
 	failedKeepAlive chan struct{}
+	gone            chan struct{}
 	wmutex          *sync.Mutex
 	rmutex          *sync.Mutex
 	log             Logger
@@ -101,6 +138,7 @@ func newConn(ws *websocket.Conn, l Logger) *conn {
 	return &conn{
 		Conn:            ws,
 		failedKeepAlive: make(chan struct{}),
+		gone:            make(chan struct{}),
 		wmutex:          new(sync.Mutex),
 		rmutex:          new(sync.Mutex),
 		log:             l,
@@ -121,38 +159,87 @@ func (c *conn) WriteMessage(messageType int, data []byte) error {
 	return c.Conn.WriteMessage(messageType, data)
 }
 
-// doKeepAlives sends keep alive messages, and expects the proper response from the peer.
-func (c *conn) doKeepAlives(done <-chan struct{}) {
-	ticker := time.NewTicker(600 * time.Millisecond)
+// doKeepAlives sends keep alive events, and closes failedKeepAlive once the peer misses too many replies.
+func (c *conn) doKeepAlives(ka KeepAlive, replies <-chan struct{}, done <-chan struct{}) {
+	if ka.Interval <= 0 {
+		return
+	}
+	grace := ka.Grace
+	if grace <= 0 {
+		grace = ka.Interval
+	}
+	ticker := time.NewTicker(ka.Interval)
 	defer ticker.Stop()
+	var (
+		missed   int
+		timer    *time.Timer
+		deadline <-chan time.Time
+	)
 	for {
 		select {
 		case <-done:
+			if timer != nil {
+				timer.Stop()
+			}
 			return
 		case <-ticker.C:
-			err := c.sendKeepAlive()
+			err := c.sendKeepAlive(ka.Format)
 			if err != nil {
 				c.log.Logf("sendKeepAlive: %s", err)
 				return
 			}
-			_, message, err := c.ReadMessage()
-			if err != nil {
-				c.log.Logf("ReadMessage: %s", err)
+			// Only the oldest unanswered keep alive is timed, so a slow reply to it also covers later ones.
+			if deadline == nil {
+				timer = time.NewTimer(grace)
+				deadline = timer.C
+			}
+		case <-replies:
+			missed = 0
+			if timer != nil {
+				timer.Stop()
+			}
+			deadline = nil
+		case <-deadline:
+			deadline = nil
+			missed++
+			if missed > ka.MaxMissed {
+				c.log.Logf("Missed %d keep alive responses, closing connection.", missed)
+				close(c.failedKeepAlive)
 				return
 			}
-			if !bytes.Equal(message, []byte(keepAliveMsg)) {
-				c.log.Logf("Did not receive expected keep alive response, closing connection.")
-				c.failedKeepAlive <- struct{}{}
+		}
+	}
+}
+
+// readReplies reads messages from the peer, and signals replies for each keep alive response.
+// gone is closed once the peer can no longer be read from.
+func (c *conn) readReplies(replies chan<- struct{}, done <-chan struct{}) {
+	defer close(c.gone)
+	for {
+		_, message, err := c.ReadMessage()
+		if err != nil {
+			select {
+			case <-done:
+			default:
+				c.log.Logf("ReadMessage: %s", err)
 			}
+			return
+		}
+		if !bytes.Equal(message, []byte(keepAliveMsg)) {
+			c.log.Logf("Did not receive expected keep alive response: %s", message)
+			continue
+		}
+		select {
+		case replies <- struct{}{}:
+		default:
 		}
 	}
 }
 
 // sendKeepAlive sends a keep alive message to its peer.
-func (c *conn) sendKeepAlive() error {
-	now := time.Now()
-	ts := now.Format("15:04:05")
-	message := fmt.Sprintf("![k[alive],v[%s]!", ts)
+func (c *conn) sendKeepAlive(format string) error {
+	ts := time.Now().Format(format)
+	message := fmt.Sprintf("![k[alive],v[%s]]!", ts)
 	err := c.WriteMessage(websocket.TextMessage, []byte(message))
 	if err != nil {
 		return err
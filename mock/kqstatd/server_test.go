@@ -0,0 +1,170 @@
+package kqstatd_test
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rickyninja/kqstat"
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/internal/testutil"
+	"github.com/rickyninja/kqstat/mock/kqstatd"
+)
+
+// stats is replayed in tests that don't depend on keep alive timing, since a replay floods the connection.
+const stats = `![k[spawn],v[10,False]]!
+![k[playerKill],v[1190,860,8,5,Worker]]!
+`
+
+func TestReplay_KeepAliveFormat(t *testing.T) {
+	t.Parallel()
+	replay := newReplay(t, stats, kqstatd.KeepAlive{Interval: 10 * time.Millisecond, Format: kqstatd.DefaultKeepAlive.Format})
+	ws := dial(t, testutil.Serve(t, replay))
+	defer ws.Close()
+	re := regexp.MustCompile(`^!\[k\[alive\],v\[\d{1,2}:\d{2}:\d{2} [AP]M\]\]!$`)
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(message), "![k[alive]") {
+			continue
+		}
+		if !re.Match(message) {
+			t.Errorf("wrong alive format: %s", message)
+		}
+		ev, err := event.Parse(string(message))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := ev.(event.Alive); !ok {
+			t.Errorf("expected alive got %#v", ev)
+		}
+		return
+	}
+}
+
+func TestReplay_KeepAliveMissed(t *testing.T) {
+	t.Parallel()
+	replay := newReplay(t, "", kqstatd.KeepAlive{
+		Interval:  20 * time.Millisecond,
+		Format:    kqstatd.DefaultKeepAlive.Format,
+		Grace:     10 * time.Millisecond,
+		MaxMissed: 1,
+	})
+	ws := dial(t, testutil.Serve(t, replay))
+	defer ws.Close()
+	start := time.Now()
+	alives := 0
+	for {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			break
+		}
+		if strings.HasPrefix(string(message), "![k[alive]") {
+			alives++
+		}
+		if time.Since(start) > 5*time.Second {
+			t.Fatal("connection wasn't closed after missing keep alive responses")
+		}
+	}
+	if alives < 2 {
+		t.Errorf("closed before MaxMissed was exceeded, got %d alive events", alives)
+	}
+}
+
+func TestReplay_KeepAliveTolerance(t *testing.T) {
+	t.Parallel()
+	// Each unanswered keep alive is missed well before the next is sent, while each reply has most of the grace
+	// period to arrive, so a slow machine doesn't turn a reply into a second miss.
+	replay := newReplay(t, "", kqstatd.KeepAlive{
+		Interval:  100 * time.Millisecond,
+		Format:    kqstatd.DefaultKeepAlive.Format,
+		Grace:     60 * time.Millisecond,
+		MaxMissed: 1,
+	})
+	ws := dial(t, testutil.Serve(t, replay))
+	defer ws.Close()
+	// Reply to every other keep alive, which stays within the tolerance of 1 missed reply.
+	alives := 0
+	for alives < 10 {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatalf("connection closed after %d alive events: %s", alives, err)
+		}
+		if !strings.HasPrefix(string(message), "![k[alive]") {
+			continue
+		}
+		alives++
+		if alives%2 == 0 {
+			err = ws.WriteMessage(websocket.TextMessage, []byte("![k[im alive],v[]]!"))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestReplay_KeepAliveClient(t *testing.T) {
+	t.Parallel()
+	replay := newReplay(t, "", kqstatd.KeepAlive{
+		Interval:  10 * time.Millisecond,
+		Format:    kqstatd.DefaultKeepAlive.Format,
+		Grace:     50 * time.Millisecond,
+		MaxMissed: 0,
+	})
+	cl, err := kqstat.NewClient(testutil.Serve(t, replay), testutil.NopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+	alives := 0
+	for alives < 10 {
+		ev, err := cl.GetEvent()
+		if err != nil {
+			t.Fatalf("connection closed after %d alive events: %s", alives, err)
+		}
+		if _, ok := ev.(event.Alive); ok {
+			alives++
+		}
+	}
+}
+
+func TestReplay_KeepAliveDisabled(t *testing.T) {
+	t.Parallel()
+	replay := newReplay(t, stats, kqstatd.KeepAlive{})
+	ws := dial(t, testutil.Serve(t, replay))
+	defer ws.Close()
+	for i := 0; i < 1000; i++ {
+		_, message, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(string(message), "![k[alive]") {
+			t.Fatalf("got alive event with keep alives disabled: %s", message)
+		}
+	}
+}
+
+func newReplay(t *testing.T, input string, ka kqstatd.KeepAlive) *kqstatd.Replay {
+	t.Helper()
+	replay, err := kqstatd.NewReplay(strings.NewReader(input), testutil.NopLogger{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay.KeepAlive = ka
+	return replay
+}
+
+func dial(t *testing.T, addr string) *websocket.Conn {
+	t.Helper()
+	u := url.URL{Scheme: "ws", Host: addr}
+	ws, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ws
+}
@@ -0,0 +1,143 @@
+// Package player maps the positions in each game to the people playing them.
+package player
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rickyninja/kqstat/event"
+)
+
+// Player is a person who plays on the cabinet.
+type Player struct {
+	// ID identifies the player across games, no matter which position they play.
+	ID string `json:"id"`
+	// Name is how the player is displayed.
+	Name string `json:"name"`
+	// Aliases are other names the player may sign in with.
+	Aliases []string `json:"aliases,omitempty"`
+	// Tags are the player's RFID tags.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Known reports if p identifies a player, rather than being an empty position.
+func (p Player) Known() bool {
+	return p.ID != ""
+}
+
+// Roster is a list of known players.
+//
+// A roster file is JSON like:
+//
+//	{"players": [{"id": "kim", "name": "Kim", "aliases": ["Kimmy"], "tags": ["04a224b2"]}]}
+type Roster struct {
+	Players []Player `json:"players"`
+}
+
+// ReadRoster decodes a roster from r.
+func ReadRoster(r io.Reader) (*Roster, error) {
+	ro := new(Roster)
+	err := json.NewDecoder(r).Decode(ro)
+	if err != nil {
+		return nil, err
+	}
+	return ro, nil
+}
+
+// LoadRoster reads the roster file at path.
+func LoadRoster(path string) (*Roster, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+	ro, err := ReadRoster(fd)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return ro, nil
+}
+
+// Registry resolves the names sent by the stats service to players, and tracks who is playing each position.
+type Registry struct {
+	players map[string]Player
+	lineup  [10]Player
+}
+
+// NewRegistry returns a *Registry knowing the players of roster, which may be nil.
+// Names not in the roster are treated as players identified by their name.
+func NewRegistry(roster *Roster) (*Registry, error) {
+	r := &Registry{players: make(map[string]Player)}
+	if roster == nil {
+		return r, nil
+	}
+	for _, p := range roster.Players {
+		if p.ID == "" {
+			p.ID = normalize(p.Name)
+		}
+		if p.ID == "" {
+			return nil, fmt.Errorf("player needs an id or name: %#v", p)
+		}
+		if p.Name == "" {
+			p.Name = p.ID
+		}
+		keys := append([]string{p.ID, p.Name}, p.Aliases...)
+		keys = append(keys, p.Tags...)
+		for _, k := range keys {
+			k = normalize(k)
+			if prev, ok := r.players[k]; ok && prev.ID != p.ID {
+				return nil, fmt.Errorf("%s is used by both %s and %s", k, prev.ID, p.ID)
+			}
+			r.players[k] = p
+		}
+	}
+	return r, nil
+}
+
+// Resolve returns the player signed in as name, which may be a name, alias or RFID tag.
+// An empty name resolves to an unknown Player.
+func (r *Registry) Resolve(name string) Player {
+	k := normalize(name)
+	if k == "" {
+		return Player{}
+	}
+	if p, ok := r.players[k]; ok {
+		return p
+	}
+	return Player{ID: k, Name: strings.TrimSpace(name)}
+}
+
+// Add updates the lineup with the players from a playernames event.  Other events are ignored.
+func (r *Registry) Add(ev event.Event) {
+	names, ok := ev.(event.PlayerNames)
+	if !ok {
+		return
+	}
+	for i := range r.lineup {
+		r.lineup[i] = Player{}
+		if i < len(names) {
+			r.lineup[i] = r.Resolve(names[i])
+		}
+	}
+}
+
+// Who returns the player at a position in the current lineup.
+func (r *Registry) Who(b event.Bee) Player {
+	if !b.Valid() {
+		return Player{}
+	}
+	return r.lineup[b-1]
+}
+
+// Lineup returns the player at each position, indexed by Bee minus one.
+func (r *Registry) Lineup() [10]Player {
+	return r.lineup
+}
+
+// normalize returns the key a name is looked up by.
+func normalize(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
@@ -0,0 +1,71 @@
+package player
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rickyninja/kqstat/event"
+)
+
+const roster = `{"players": [
+	{"id": "kim", "name": "Kim", "aliases": ["Kimmy"], "tags": ["04a224b2"]},
+	{"name": "Tyler M.", "aliases": ["tm"]}
+]}`
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+	ro, err := ReadRoster(strings.NewReader(roster))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg, err := NewRegistry(ro)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		id   string
+	}{
+		{"Kim", "kim"},
+		{" kimmy ", "kim"},
+		{"04A224B2", "kim"},
+		{"TM", "tyler m."},
+		{"Tyler M.", "tyler m."},
+		{"Logan", "logan"},
+		{"", ""},
+	}
+	for _, tc := range cases {
+		if got := reg.Resolve(tc.name); got.ID != tc.id {
+			t.Errorf("wrong ID for %q, got %q want %q", tc.name, got.ID, tc.id)
+		}
+	}
+	if got := reg.Resolve("kimmy").Name; got != "Kim" {
+		t.Errorf("wrong Name, got %s want %s", got, "Kim")
+	}
+
+	reg.Add(event.PlayerNames{"Logan", "Kimmy", "", "", "", "", "", "", "", "tm"})
+	if got := reg.Who(event.BlueQueen); got.ID != "kim" {
+		t.Errorf("wrong blue queen, got %s want %s", got.ID, "kim")
+	}
+	if got := reg.Who(event.GoldStripes); got.Known() {
+		t.Errorf("expected an unknown player, got %#v", got)
+	}
+	reg.Add(event.PlayerNames{"Kim", "Logan", "", "", "", "", "", "", "", ""})
+	if got := reg.Who(event.GoldQueen); got.ID != "kim" {
+		t.Errorf("wrong gold queen, got %s want %s", got.ID, "kim")
+	}
+	if got := reg.Who(event.BlueChecks); got.Known() {
+		t.Errorf("expected lineup to be replaced, got %#v", got)
+	}
+}
+
+func TestNewRegistry_Conflict(t *testing.T) {
+	t.Parallel()
+	ro := &Roster{Players: []Player{
+		{ID: "a", Name: "Sam", Aliases: []string{"sammy"}},
+		{ID: "b", Name: "Samantha", Aliases: []string{"Sammy"}},
+	}}
+	if _, err := NewRegistry(ro); err == nil {
+		t.Error("expected an error for an alias used by two players")
+	}
+}
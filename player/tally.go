@@ -0,0 +1,80 @@
+package player
+
+import (
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/game"
+)
+
+// Totals are a player's stats summed over every game they played, in any position.
+type Totals struct {
+	Player Player
+	// Games is how many games the player played.
+	Games int
+	// Wins is how many of those games the player's team won.
+	Wins int
+	// Positions is how many games the player played at each position.
+	Positions map[event.Bee]int
+	// The remaining fields are sums of the game.PlayerStats fields of the same name.
+	Kills         int
+	Deaths        int
+	QueenKills    int
+	Berries       int
+	KickIns       int
+	SnailDistance int
+}
+
+// Tally sums the stats of each player over many games.
+type Tally struct {
+	totals map[string]*Totals
+	order  []string
+}
+
+// NewTally returns an empty *Tally.
+func NewTally() *Tally {
+	return &Tally{totals: make(map[string]*Totals)}
+}
+
+// AddGame credits each player in lineup with their stats from a game.  Unknown players are skipped.
+func (t *Tally) AddGame(lineup [10]Player, s *game.Stats) {
+	for i, p := range lineup {
+		if !p.Known() {
+			continue
+		}
+		tot, ok := t.totals[p.ID]
+		if !ok {
+			tot = &Totals{Player: p, Positions: make(map[event.Bee]int)}
+			t.totals[p.ID] = tot
+			t.order = append(t.order, p.ID)
+		}
+		ps := s.Players[i]
+		tot.Games++
+		tot.Positions[ps.Bee]++
+		if s.Victory.Team != "" && s.Victory.Team == ps.Bee.Team() {
+			tot.Wins++
+		}
+		tot.Kills += ps.Kills
+		tot.Deaths += ps.Deaths
+		tot.QueenKills += ps.QueenKills
+		tot.Berries += ps.Berries
+		tot.KickIns += ps.KickIns
+		tot.SnailDistance += ps.SnailDistance
+	}
+}
+
+// Get returns the totals of the player with id.
+func (t *Tally) Get(id string) (Totals, bool) {
+	tot, ok := t.totals[id]
+	if !ok {
+		return Totals{}, false
+	}
+	return *tot, true
+}
+
+// All returns the totals of every player, in the order they first played.
+func (t *Tally) All() []Totals {
+	all := make([]Totals, len(t.order))
+	for i, id := range t.order {
+		all[i] = *t.totals[id]
+	}
+	return all
+}
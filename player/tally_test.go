@@ -0,0 +1,58 @@
+package player
+
+import (
+	"testing"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/game"
+)
+
+func TestTally(t *testing.T) {
+	t.Parallel()
+	reg, err := NewRegistry(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tally := NewTally()
+	// Kim plays gold queen and wins, then switches to blue stripes and loses.
+	games := []struct {
+		names  event.PlayerNames
+		kill   event.PlayerKill
+		winner event.Team
+	}{
+		{
+			event.PlayerNames{"Kim", "Logan", "", "", "", "", "", "", "", ""},
+			event.PlayerKill{Slayer: event.GoldQueen, Slain: event.BlueQueen, SlainClass: event.Queen},
+			event.Gold,
+		},
+		{
+			event.PlayerNames{"Logan", "", "", "Kim", "", "", "", "", "", ""},
+			event.PlayerKill{Slayer: event.BlueStripes, Slain: event.GoldStripes, SlainClass: event.Worker},
+			event.Gold,
+		},
+	}
+	for _, g := range games {
+		reg.Add(g.names)
+		s := game.NewStats()
+		s.Add(g.kill)
+		s.Add(event.Victory{Team: g.winner, Type: event.Military})
+		tally.AddGame(reg.Lineup(), s)
+	}
+	kim, ok := tally.Get("kim")
+	if !ok {
+		t.Fatal("missing totals for kim")
+	}
+	if kim.Games != 2 || kim.Wins != 1 || kim.Kills != 2 || kim.QueenKills != 1 {
+		t.Errorf("wrong totals for kim: %#v", kim)
+	}
+	if kim.Positions[event.GoldQueen] != 1 || kim.Positions[event.BlueStripes] != 1 {
+		t.Errorf("wrong positions for kim: %v", kim.Positions)
+	}
+	logan, _ := tally.Get("logan")
+	if logan.Games != 2 || logan.Wins != 1 || logan.Deaths != 1 {
+		t.Errorf("wrong totals for logan: %#v", logan)
+	}
+	if all := tally.All(); len(all) != 2 || all[0].Player.ID != "kim" {
+		t.Errorf("wrong All: %#v", all)
+	}
+}
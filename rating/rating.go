@@ -0,0 +1,226 @@
+// Package rating maintains Elo ratings of players and teams from the results of games.
+package rating
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/player"
+)
+
+const (
+	// DefaultInitial is the rating of a newly seen player or team.
+	DefaultInitial = 1500
+	// DefaultK is how much a single game can move a rating.
+	DefaultK = 32
+)
+
+// Rating is the rating of a player or team.
+type Rating struct {
+	// Rating is the Elo rating.
+	Rating float64 `json:"rating"`
+	// Games is how many rated games were played.
+	Games int `json:"games"`
+	// Wins is how many rated games were won.
+	Wins int `json:"wins"`
+}
+
+// Result is the outcome of a game between two teams, identified by the IDs of their players.
+type Result struct {
+	Gold []string
+	Blue []string
+	// Winner is Gold or Blue.
+	Winner event.Team
+}
+
+// NewResult returns the Result of a game, with its players identified by reg.
+// It's false when the game can't be rated, because the players of a team are all unknown or nobody won.
+func NewResult(reg *player.Registry, seg game.Segment) (Result, bool) {
+	res := Result{Winner: seg.Victory.Team}
+	for i, name := range seg.Names {
+		p := reg.Resolve(name)
+		if !p.Known() {
+			continue
+		}
+		switch event.Bee(i + 1).Team() {
+		case event.Gold:
+			res.Gold = append(res.Gold, p.ID)
+		case event.Blue:
+			res.Blue = append(res.Blue, p.ID)
+		}
+	}
+	ok := len(res.Gold) > 0 && len(res.Blue) > 0 && (res.Winner == event.Gold || res.Winner == event.Blue)
+	return res, ok
+}
+
+// Ratings are the ratings of every player and team seen.
+type Ratings struct {
+	// K is how much a single game can move a rating.
+	K float64 `json:"k"`
+	// Initial is the rating of a newly seen player or team.
+	Initial float64 `json:"initial"`
+	// Players are the ratings of individual players, by player ID.
+	Players map[string]*Rating `json:"players"`
+	// Teams are the ratings of each group of players who played together, by TeamID.
+	Teams map[string]*Rating `json:"teams"`
+}
+
+// New returns empty *Ratings using the default constants.
+func New() *Ratings {
+	return &Ratings{
+		K:       DefaultK,
+		Initial: DefaultInitial,
+		Players: make(map[string]*Rating),
+		Teams:   make(map[string]*Rating),
+	}
+}
+
+// Load reads ratings saved at path, or returns New ratings if the file doesn't exist.
+func Load(path string) (*Ratings, error) {
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return New(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r := New()
+	err = json.Unmarshal(buf, r)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Save writes the ratings to path, replacing the file only once the write succeeds.
+func (r *Ratings) Save(path string) error {
+	buf, err := json.MarshalIndent(r, "", "\t")
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(append(buf, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// TeamID returns the ID of a team made up of players, no matter what order they're listed in.
+func TeamID(players []string) string {
+	ids := append([]string(nil), players...)
+	sort.Strings(ids)
+	return strings.Join(ids, "+")
+}
+
+// Record updates the ratings of the players and teams in a game.
+//
+// Each team's players are rated as a team by their average rating, and each player's rating moves by the
+// same amount.  Teams are also rated as a unit by TeamID.
+// It returns an error and changes nothing when a team has no players, or the winner isn't Gold or Blue.
+func (r *Ratings) Record(res Result) error {
+	if len(res.Gold) == 0 || len(res.Blue) == 0 {
+		return fmt.Errorf("can't rate a game with %d gold and %d blue players", len(res.Gold), len(res.Blue))
+	}
+	if res.Winner != event.Gold && res.Winner != event.Blue {
+		return fmt.Errorf("can't rate a game won by %q", res.Winner)
+	}
+	goldWon := res.Winner == event.Gold
+	gold := r.players(res.Gold)
+	blue := r.players(res.Blue)
+	goldDelta := r.delta(average(gold), average(blue), goldWon)
+	blueDelta := r.delta(average(blue), average(gold), !goldWon)
+	apply(gold, goldDelta, goldWon)
+	apply(blue, blueDelta, !goldWon)
+
+	goldTeam := r.get(r.Teams, TeamID(res.Gold))
+	blueTeam := r.get(r.Teams, TeamID(res.Blue))
+	goldDelta = r.delta(goldTeam.Rating, blueTeam.Rating, goldWon)
+	blueDelta = r.delta(blueTeam.Rating, goldTeam.Rating, !goldWon)
+	apply([]*Rating{goldTeam}, goldDelta, goldWon)
+	apply([]*Rating{blueTeam}, blueDelta, !goldWon)
+	return nil
+}
+
+// Ranked returns the IDs of the ratings in m, from highest to lowest rated.
+func Ranked(m map[string]*Rating) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if m[ids[i]].Rating != m[ids[j]].Rating {
+			return m[ids[i]].Rating > m[ids[j]].Rating
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// Expected returns the probability that a side rated a beats a side rated b.
+func Expected(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+// delta returns how much the rating of a side rated own changes after playing a side rated opp.
+func (r *Ratings) delta(own, opp float64, won bool) float64 {
+	score := 0.0
+	if won {
+		score = 1
+	}
+	return r.K * (score - Expected(own, opp))
+}
+
+// players returns the ratings of each player ID, adding any that are new.
+func (r *Ratings) players(ids []string) []*Rating {
+	rs := make([]*Rating, len(ids))
+	for i, id := range ids {
+		rs[i] = r.get(r.Players, id)
+	}
+	return rs
+}
+
+// get returns the rating for id in m, adding it if it's new.
+func (r *Ratings) get(m map[string]*Rating, id string) *Rating {
+	rt, ok := m[id]
+	if !ok {
+		rt = &Rating{Rating: r.Initial}
+		m[id] = rt
+	}
+	return rt
+}
+
+// apply moves each rating by delta, and counts the game.
+func apply(rs []*Rating, delta float64, won bool) {
+	for _, rt := range rs {
+		rt.Rating += delta
+		rt.Games++
+		if won {
+			rt.Wins++
+		}
+	}
+}
+
+// average returns the mean of the ratings.
+func average(rs []*Rating) float64 {
+	sum := 0.0
+	for _, rt := range rs {
+		sum += rt.Rating
+	}
+	return sum / float64(len(rs))
+}
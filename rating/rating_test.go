@@ -0,0 +1,129 @@
+package rating
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/player"
+)
+
+func TestRecord(t *testing.T) {
+	t.Parallel()
+	r := New()
+	if err := r.Record(Result{Gold: []string{"a", "b"}, Blue: []string{"c", "d"}, Winner: event.Gold}); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]float64{"a": 1516, "b": 1516, "c": 1484, "d": 1484} {
+		if got := r.Players[id].Rating; !near(got, want) {
+			t.Errorf("wrong rating for %s, got %f want %f", id, got, want)
+		}
+	}
+	if got := r.Teams["a+b"].Rating; !near(got, 1516) {
+		t.Errorf("wrong team rating, got %f want %f", got, 1516.0)
+	}
+	// An upset moves ratings further than the expected result.
+	if err := r.Record(Result{Gold: []string{"b", "a"}, Blue: []string{"d", "c"}, Winner: event.Blue}); err != nil {
+		t.Fatal(err)
+	}
+	gain := r.Players["c"].Rating - 1484
+	if gain <= 16 {
+		t.Errorf("upset should gain more than 16, got %f", gain)
+	}
+	a := r.Players["a"]
+	if a.Games != 2 || a.Wins != 1 {
+		t.Errorf("wrong games or wins for a: %#v", a)
+	}
+	if got := Ranked(r.Players); got[0] != "c" && got[0] != "d" {
+		t.Errorf("wrong ranking: %v", got)
+	}
+}
+
+func TestRecord_Unratable(t *testing.T) {
+	t.Parallel()
+	r := New()
+	for _, res := range []Result{
+		{Gold: []string{"a"}, Winner: event.Gold},
+		{Blue: []string{"b"}, Winner: event.Blue},
+		{Gold: []string{"a"}, Blue: []string{"b"}, Winner: event.Red},
+	} {
+		if err := r.Record(res); err == nil {
+			t.Errorf("%+v: expected an error", res)
+		}
+	}
+	// Nothing is rated, so no NaN ratings are saved.
+	if len(r.Players) != 0 || len(r.Teams) != 0 {
+		t.Errorf("got ratings %v %v, want none", r.Players, r.Teams)
+	}
+}
+
+func TestExpected(t *testing.T) {
+	t.Parallel()
+	if got := Expected(1500, 1500); !near(got, 0.5) {
+		t.Errorf("wrong Expected for equal ratings, got %f", got)
+	}
+	if got := Expected(1900, 1500); !near(got, 1/1.1) {
+		t.Errorf("wrong Expected for a 400 point favorite, got %f", got)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "rating")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ratings.json")
+	r, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Players) != 0 {
+		t.Errorf("expected no ratings for a missing file, got %d", len(r.Players))
+	}
+	if err := r.Record(Result{Gold: []string{"a"}, Blue: []string{"b"}, Winner: event.Blue}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Players["b"].Rating != r.Players["b"].Rating || got.Teams["a"].Games != 1 || got.K != DefaultK {
+		t.Errorf("wrong ratings after Load: %#v", got)
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	t.Parallel()
+	reg, err := player.NewRegistry(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seg := game.Segment{
+		Names:   event.PlayerNames{"Kim", "Logan", "Max", "", "", "", "", "", "", ""},
+		Victory: event.Victory{Team: event.Blue, Type: event.Snail},
+	}
+	res, ok := NewResult(reg, seg)
+	if !ok {
+		t.Fatal("expected a rated result")
+	}
+	if TeamID(res.Gold) != "kim+max" || TeamID(res.Blue) != "logan" || res.Winner != event.Blue {
+		t.Errorf("wrong Result: %#v", res)
+	}
+	seg.Names = event.PlayerNames{"Kim", "", "", "", "", "", "", "", "", ""}
+	if _, ok := NewResult(reg, seg); ok {
+		t.Error("a game without any known blue players shouldn't be rated")
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
@@ -0,0 +1,20 @@
+/*
+Package record captures the events sent by a stats service to disk, and re-serves them to other clients.
+
+# Recording format
+
+A recording is a text file of JSON objects, one per line.  The first line is a header describing the session:
+
+	{"format":"kqstat-recording","version":1,"cabinet":"blue","scene":"Portland","started":"2018-10-20T12:39:04-07:00"}
+
+Each following line is an event, with a sequence number starting at 1, the time it was received, and its text as sent
+by the stats service:
+
+	{"seq":1,"time":"2018-10-20T12:39:04.5-07:00","line":"![k[alive],v[12:39:04 PM]]!"}
+
+Another header may appear later in the same file when recording resumes, and applies to the events following it.
+
+Reader also imports legacy recordings, which are the raw event text from the stats service one per line, optionally
+preceded by an RFC 3339 receive time and a tab.  Events in legacy recordings are numbered by line.
+*/
+package record
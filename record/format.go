@@ -0,0 +1,168 @@
+package record
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/rickyninja/kqstat/event"
+)
+
+const (
+	// formatName identifies a recording header.
+	formatName = "kqstat-recording"
+	// formatVersion is the version of the recording format written by Writer.
+	formatVersion = 1
+)
+
+// Header is metadata about a recording session.
+type Header struct {
+	// Format is always "kqstat-recording".
+	Format string `json:"format"`
+	// Version is the version of the recording format.
+	Version int `json:"version"`
+	// Cabinet is the name of the cabinet the events came from.
+	Cabinet string `json:"cabinet,omitempty"`
+	// Scene is the name of the scene or venue the cabinet is at.
+	Scene string `json:"scene,omitempty"`
+	// Started is when the session began.
+	Started time.Time `json:"started"`
+	// Meta is any other information about the session.
+	Meta map[string]string `json:"meta,omitempty"`
+}
+
+// Envelope is an event in a recording, along with when it was received.
+type Envelope struct {
+	// Seq is the position of the event within its session, starting at 1.
+	Seq uint64 `json:"seq"`
+	// Time is when the event was received.  It's the zero time for legacy recordings lacking receive times.
+	Time time.Time `json:"time"`
+	// Line is the event text as sent by the stats service.
+	Line string `json:"line"`
+	// Event is Line after parsing, and is nil when parsing fails.
+	Event event.Event `json:"-"`
+	// Err is why parsing Line failed.
+	Err error `json:"-"`
+}
+
+// Writer writes events to a recording.
+type Writer struct {
+	enc *json.Encoder
+	seq uint64
+}
+
+// NewWriter writes the session header to w, and returns a *Writer for the events that follow.
+func NewWriter(w io.Writer, h Header) (*Writer, error) {
+	h.Format = formatName
+	h.Version = formatVersion
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(h)
+	if err != nil {
+		return nil, err
+	}
+	return &Writer{enc: enc}, nil
+}
+
+// Write records line as received at t.  line is typically the text returned by kqstat.Client.GetRawEvent.
+func (w *Writer) Write(t time.Time, line string) error {
+	w.seq++
+	return w.enc.Encode(Envelope{
+		Seq:  w.seq,
+		Time: t,
+		Line: strings.TrimRight(line, "\r\n"),
+	})
+}
+
+// Reader reads events from a recording, or a legacy recording.  It reads lines with an event.Reader, so gzipped
+// recordings, \r\n line endings and blank lines are handled the same way as in logs, and undecodable lines are
+// returned as an *event.LineError.
+type Reader struct {
+	lines  *event.Reader
+	header Header
+	seq    uint64
+}
+
+// NewReader returns a *Reader reading a recording from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{lines: event.NewReader(r)}
+}
+
+// Line returns the line number of the recording that the last event returned by Next was read from, starting at 1.
+func (r *Reader) Line() int {
+	return r.lines.Line()
+}
+
+// Header returns the header of the session that the last event returned by Next belongs to.
+// Legacy recordings have no header, so it's the zero Header for them.
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the next event in the recording, or io.EOF after the last one.
+// Events that fail to parse are still returned, with Err set.
+func (r *Reader) Next() (Envelope, error) {
+	for {
+		line, err := r.lines.ReadLine()
+		if err != nil {
+			return Envelope{}, err
+		}
+		env, err := r.decode(line)
+		if err != nil {
+			return env, &event.LineError{Line: r.lines.Line(), Err: err}
+		}
+		if env.Line == "" {
+			// A header was decoded.
+			continue
+		}
+		env.Event, env.Err = event.Parse(env.Line)
+		return env, nil
+	}
+}
+
+// decode decodes a line of a recording.  The returned Envelope has an empty Line when line is a header.
+func (r *Reader) decode(line []byte) (Envelope, error) {
+	env := Envelope{}
+	if line[0] != '{' {
+		return r.decodeLegacy(string(line))
+	}
+	var rec struct {
+		Header
+		Envelope
+	}
+	err := json.Unmarshal(line, &rec)
+	if err != nil {
+		return env, err
+	}
+	if rec.Format != "" {
+		if rec.Format != formatName {
+			return env, fmt.Errorf("unknown format: %s", rec.Format)
+		}
+		if rec.Version > formatVersion {
+			return env, fmt.Errorf("unsupported version: %d", rec.Version)
+		}
+		r.header = rec.Header
+		return env, nil
+	}
+	if rec.Line == "" {
+		return env, fmt.Errorf("event is missing its line")
+	}
+	return rec.Envelope, nil
+}
+
+// decodeLegacy decodes a line of raw event text, which may be preceded by its receive time and a tab.
+func (r *Reader) decodeLegacy(line string) (Envelope, error) {
+	r.seq++
+	env := Envelope{Seq: r.seq, Line: line}
+	if i := strings.IndexByte(line, '\t'); i >= 0 {
+		t, err := time.Parse(time.RFC3339Nano, line[:i])
+		if err != nil {
+			return env, err
+		}
+		env.Time = t
+		env.Line = line[i+1:]
+	}
+	return env, nil
+}
@@ -0,0 +1,184 @@
+package record
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/internal/testutil"
+)
+
+func TestWriterReader(t *testing.T) {
+	t.Parallel()
+	buf := new(bytes.Buffer)
+	start := time.Date(2018, 10, 20, 12, 39, 4, 0, time.UTC)
+	w, err := NewWriter(buf, Header{Cabinet: "blue", Scene: "Portland", Started: start})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []string{
+		"![k[alive],v[12:39:04 PM]]!\n",
+		"![k[playerKill],v[750,861,1,2,Queen]]!",
+		"![k[bogus]]!",
+	}
+	for i, line := range lines {
+		err := w.Write(start.Add(time.Duration(i)*time.Second), line)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := NewReader(buf)
+	for i, line := range lines {
+		env, err := r.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if env.Seq != uint64(i+1) {
+			t.Errorf("wrong Seq, got %d want %d", env.Seq, i+1)
+		}
+		if want := start.Add(time.Duration(i) * time.Second); !env.Time.Equal(want) {
+			t.Errorf("wrong Time, got %s want %s", env.Time, want)
+		}
+		if want := strings.TrimSuffix(line, "\n"); env.Line != want {
+			t.Errorf("wrong Line, got %s want %s", env.Line, want)
+		}
+	}
+	h := r.Header()
+	if h.Cabinet != "blue" || h.Scene != "Portland" || !h.Started.Equal(start) || h.Version != formatVersion {
+		t.Errorf("wrong Header, got %#v", h)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestReader_Gzip(t *testing.T) {
+	t.Parallel()
+	buf := new(bytes.Buffer)
+	gz := gzip.NewWriter(buf)
+	w, err := NewWriter(gz, Header{Cabinet: "blue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Write(time.Now(), "![k[glance],v[1,2]]!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gz.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewReader(buf)
+	env, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := env.Event.(event.Glance); !ok || r.Header().Cabinet != "blue" || r.Line() != 2 {
+		t.Errorf("got %#v on line %d with header %#v", env.Event, r.Line(), r.Header())
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestReader_ParseError(t *testing.T) {
+	t.Parallel()
+	r := NewReader(strings.NewReader(`{"format":"kqstat-recording","version":1,"started":"2018-10-20T12:39:04Z"}
+{"seq":1,"time":"2018-10-20T12:39:04Z","line":"![k[bogus]]!"}
+`))
+	env, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Err == nil {
+		t.Errorf("expected a parse error for %s", env.Line)
+	}
+	if env.Event != nil {
+		t.Errorf("expected no event, got %#v", env.Event)
+	}
+}
+
+func TestReader_Legacy(t *testing.T) {
+	t.Parallel()
+	fd, err := os.Open(testutil.BB3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	r := NewReader(fd)
+	n := 0
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+		if env.Seq != uint64(n) {
+			t.Fatalf("wrong Seq, got %d want %d", env.Seq, n)
+		}
+		if env.Err != nil {
+			t.Errorf("line %d: %s", n, env.Err)
+		}
+		if !env.Time.IsZero() {
+			t.Errorf("line %d: expected no receive time, got %s", n, env.Time)
+		}
+	}
+	if n != 5028 {
+		t.Errorf("wrong number of events, got %d want %d", n, 5028)
+	}
+}
+
+func TestReader_LegacyTimestamps(t *testing.T) {
+	t.Parallel()
+	r := NewReader(strings.NewReader("2018-10-20T12:39:04.5Z\t![k[spawn],v[3,False]]!\r\n\n"))
+	env, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2018, 10, 20, 12, 39, 4, 5e8, time.UTC); !env.Time.Equal(want) {
+		t.Errorf("wrong Time, got %s want %s", env.Time, want)
+	}
+	if want := (event.Spawn{Who: event.GoldStripes}); env.Event != want {
+		t.Errorf("wrong Event, got %#v want %#v", env.Event, want)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestReader_UnknownFormat(t *testing.T) {
+	t.Parallel()
+	r := NewReader(strings.NewReader(`{"format":"something-else","version":1}` + "\n"))
+	if _, err := r.Next(); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
+
+func TestReader_LineError(t *testing.T) {
+	t.Parallel()
+	r := NewReader(strings.NewReader(`{"format":"kqstat-recording","version":1}
+
+{"seq":1,"line":
+{"seq":2,"line":"![k[alive],v[12:39:04 PM]]!"}
+`))
+	_, err := r.Next()
+	le, ok := err.(*event.LineError)
+	if !ok || le.Line != 3 {
+		t.Fatalf("expected a LineError for line 3, got %v", err)
+	}
+	env, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if env.Seq != 2 || r.Line() != 4 {
+		t.Errorf("got seq %d on line %d, want seq 2 on line 4", env.Seq, r.Line())
+	}
+}
@@ -0,0 +1,99 @@
+package record
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// peerBuffer is how many lines may be queued for a client before it's considered too slow, and disconnected.
+const peerBuffer = 512
+
+// Hub re-serves lines received from a stats service to any number of websocket clients, as though it were the stats service.
+type Hub struct {
+	mutex *sync.Mutex
+	peers map[chan string]struct{}
+	log   Logger
+}
+
+// NewHub constructs a *Hub with no clients.
+func NewHub(l Logger) *Hub {
+	return &Hub{
+		mutex: new(sync.Mutex),
+		peers: make(map[chan string]struct{}),
+		log:   l,
+	}
+}
+
+// Publish sends line to every connected client.
+func (h *Hub) Publish(line string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	for p := range h.peers {
+		select {
+		case p <- line:
+		default:
+			h.log.Logf("Client is too slow, disconnecting.")
+			delete(h.peers, p)
+			close(p)
+		}
+	}
+}
+
+// ServeHTTP does http.Handler.
+func (h *Hub) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	var upgrader = websocket.Upgrader{} // use default options
+	ws, err := upgrader.Upgrade(rw, req, nil)
+	if err != nil {
+		h.log.Logf("websocket Upgrade: %s", err)
+		return
+	}
+	defer ws.Close()
+	lines := h.subscribe()
+	defer h.unsubscribe(lines)
+	// Keep alive replies are meant for the stats service, which the Hub already replies to, so they're discarded.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			err := ws.WriteMessage(websocket.TextMessage, []byte(line))
+			if err != nil {
+				h.log.Logf("websocket WriteMessage: %s", err)
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+// subscribe registers a new client, and returns the channel it receives lines on.
+func (h *Hub) subscribe() chan string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	p := make(chan string, peerBuffer)
+	h.peers[p] = struct{}{}
+	return p
+}
+
+// unsubscribe removes a client, unless Publish already removed it.
+func (h *Hub) unsubscribe(p chan string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if _, ok := h.peers[p]; ok {
+		delete(h.peers, p)
+		close(p)
+	}
+}
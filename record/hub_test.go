@@ -0,0 +1,51 @@
+package record
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rickyninja/kqstat"
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/internal/testutil"
+)
+
+func TestHub(t *testing.T) {
+	t.Parallel()
+	hub := NewHub(testutil.NopLogger{})
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go http.Serve(l, hub)
+	cl, err := kqstat.NewClient(l.Addr().String(), t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+	// The client may not be subscribed yet, so publish until it receives something.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				hub.Publish("![k[playerKill],v[1190,860,8,5,Worker]]!")
+			}
+		}
+	}()
+	ev, err := cl.GetEvent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := event.PlayerKill{X: 1190, Y: 860, Slayer: event.BlueSkulls, Slain: event.GoldAbs, SlainClass: event.Worker}
+	if got, ok := ev.(event.PlayerKill); !ok || got != want {
+		t.Errorf("wrong event, got %#v want %#v", ev, want)
+	}
+}
@@ -0,0 +1,125 @@
+package record
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rickyninja/kqstat/event"
+)
+
+type Logger interface {
+	Logf(format string, a ...interface{})
+}
+
+// Rotation controls when a Recorder starts writing a new file.
+type Rotation int
+
+const (
+	// PerGame starts a new file once a game's gameend and victory events have been recorded.
+	PerGame Rotation = iota
+	// PerNight starts a new file for each night of play.
+	PerNight
+)
+
+// nightEnd is how far past midnight a night of play is considered to last.
+const nightEnd = 6 * time.Hour
+
+// Recorder writes each line received from a stats service, along with when it was received, to recordings in a directory.
+type Recorder struct {
+	dir      string
+	rotation Rotation
+	header   Header
+	file     *os.File
+	writer   *Writer
+	night    string
+	gameOver bool
+	log      Logger
+}
+
+// NewRecorder creates dir if needed, and returns a *Recorder writing files into it.
+// Each file starts with h, with its Started time set to when the first event in the file was received.
+func NewRecorder(dir string, rot Rotation, h Header, l Logger) (*Recorder, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		dir:      dir,
+		rotation: rot,
+		header:   h,
+		log:      l,
+	}
+	return r, nil
+}
+
+// Record writes line to the current file, starting a new one first when required by the Rotation.
+// ev is the parsed form of line, and may be nil if line couldn't be parsed.
+func (r *Recorder) Record(t time.Time, line string, ev event.Event) error {
+	err := r.rotate(t, ev)
+	if err != nil {
+		return err
+	}
+	err = r.writer.Write(t, line)
+	if err != nil {
+		return err
+	}
+	if _, ok := ev.(event.GameEnd); ok {
+		r.gameOver = true
+	}
+	return nil
+}
+
+// Close closes the current file.
+func (r *Recorder) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	r.writer = nil
+	return err
+}
+
+// rotate opens the file that an event received at t belongs in.
+func (r *Recorder) rotate(t time.Time, ev event.Event) error {
+	var name string
+	switch r.rotation {
+	case PerGame:
+		if r.file != nil {
+			// The victory is sent right after gameend, and belongs to the same game.
+			if _, ok := ev.(event.Victory); ok || !r.gameOver {
+				return nil
+			}
+		}
+		r.gameOver = false
+		name = "game-" + t.Format("20060102-150405.000") + ".jsonl"
+	case PerNight:
+		night := t.Add(-nightEnd).Format("2006-01-02")
+		if r.file != nil && night == r.night {
+			return nil
+		}
+		r.night = night
+		name = "night-" + night + ".jsonl"
+	default:
+		return fmt.Errorf("unknown rotation: %d", r.rotation)
+	}
+	err := r.Close()
+	if err != nil {
+		r.log.Logf("Failed to close recording: %s", err)
+	}
+	path := filepath.Join(r.dir, name)
+	r.file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	h := r.header
+	h.Started = t
+	r.writer, err = NewWriter(r.file, h)
+	if err != nil {
+		return err
+	}
+	r.log.Logf("Recording to %s", path)
+	return nil
+}
@@ -0,0 +1,140 @@
+package record
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/internal/testutil"
+)
+
+func TestRecorder_PerGame(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "kqrecord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rec, err := NewRecorder(dir, PerGame, Header{Cabinet: "blue"}, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := recordFile(t, rec, testutil.BB3, time.Second)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 18 games end in the log, and the lines after the last one start another file.
+	if len(files) != 19 {
+		t.Fatalf("wrong number of files, got %d want %d", len(files), 19)
+	}
+	total := 0
+	for _, fi := range files {
+		got := readRecording(t, filepath.Join(dir, fi.Name()))
+		total += len(got)
+		last := got[len(got)-1]
+		if _, ok := last.Event.(event.Victory); !ok && fi.Name() != files[len(files)-1].Name() {
+			t.Errorf("%s should end with a victory, got %s", fi.Name(), last.Line)
+		}
+	}
+	if total != lines {
+		t.Errorf("wrong number of recorded lines, got %d want %d", total, lines)
+	}
+}
+
+func TestRecorder_PerNight(t *testing.T) {
+	t.Parallel()
+	dir, err := ioutil.TempDir("", "kqrecord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	rec, err := NewRecorder(dir, PerNight, Header{Cabinet: "blue"}, t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2018, 10, 20, 20, 0, 0, 0, time.Local)
+	for _, h := range []int{0, 3, 5, 7, 27, 30, 36} {
+		err := rec.Record(start.Add(time.Duration(h)*time.Hour), "![k[alive],v[8:00:00 PM]]!\n", event.Alive{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{
+		"night-2018-10-20.jsonl": 4,
+		"night-2018-10-21.jsonl": 2,
+		"night-2018-10-22.jsonl": 1,
+	}
+	for name, n := range want {
+		got := readRecording(t, filepath.Join(dir, name))
+		if len(got) != n {
+			t.Errorf("wrong number of lines in %s, got %d want %d", name, len(got), n)
+		}
+	}
+}
+
+// recordFile records each line of a stats log, with receive times step apart, and returns how many lines were recorded.
+func recordFile(t *testing.T, rec *Recorder, path string, step time.Duration) int {
+	t.Helper()
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	now := time.Date(2018, 10, 20, 12, 39, 4, 0, time.Local)
+	n := 0
+	scanner := bufio.NewScanner(fd)
+	for scanner.Scan() {
+		ev, _ := event.Parse(scanner.Text())
+		err := rec.Record(now, scanner.Text(), ev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(step)
+		n++
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// readRecording returns the events in a recording, checking each has a receive time.
+func readRecording(t *testing.T, path string) []Envelope {
+	t.Helper()
+	fd, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	var envs []Envelope
+	r := NewReader(fd)
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if env.Time.IsZero() {
+			t.Errorf("missing receive time in %s: %s", path, env.Line)
+		}
+		if r.Header().Cabinet != "blue" {
+			t.Errorf("wrong cabinet in %s, got %s want %s", path, r.Header().Cabinet, "blue")
+		}
+		envs = append(envs, env)
+	}
+	return envs
+}
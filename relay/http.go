@@ -0,0 +1,115 @@
+package relay
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gorilla/websocket"
+)
+
+// Handler returns an http.Handler serving clients at these paths:
+//
+//	/events  Server-Sent Events, with each message's type as the event name
+//	/ws      websocket, with a text message for each message
+//	/state   the current State as JSON
+func (r *Relay) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", r.ServeSSE)
+	mux.HandleFunc("/ws", r.ServeWebSocket)
+	mux.HandleFunc("/state", r.ServeState)
+	return mux
+}
+
+// ServeSSE serves messages as Server-Sent Events.
+func (r *Relay) ServeSSE(rw http.ResponseWriter, req *http.Request) {
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		http.Error(rw, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Access-Control-Allow-Origin", "*")
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	messages := r.subscribe()
+	defer r.unsubscribe(messages)
+	for {
+		select {
+		case m, ok := <-messages:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(m)
+			if err != nil {
+				r.log.Logf("json Marshal: %s", err)
+				continue
+			}
+			if m.Seq > 0 {
+				_, err = fmt.Fprintf(rw, "id: %d\n", m.Seq)
+				if err != nil {
+					return
+				}
+			}
+			_, err = fmt.Fprintf(rw, "event: %s\ndata: %s\n\n", m.Type, data)
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-req.Context().Done():
+			return
+		}
+	}
+}
+
+// ServeWebSocket serves messages over a websocket.
+func (r *Relay) ServeWebSocket(rw http.ResponseWriter, req *http.Request) {
+	upgrader := websocket.Upgrader{
+		// Overlays are served from anywhere, including local files.
+		CheckOrigin: func(*http.Request) bool { return true },
+	}
+	ws, err := upgrader.Upgrade(rw, req, nil)
+	if err != nil {
+		r.log.Logf("websocket Upgrade: %s", err)
+		return
+	}
+	defer ws.Close()
+	messages := r.subscribe()
+	defer r.unsubscribe(messages)
+	// Clients have nothing to say, but reading notices when they go away.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	for {
+		select {
+		case m, ok := <-messages:
+			if !ok {
+				return
+			}
+			err := ws.WriteJSON(m)
+			if err != nil {
+				r.log.Logf("websocket WriteJSON: %s", err)
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
+
+// ServeState serves the current State as JSON.
+func (r *Relay) ServeState(rw http.ResponseWriter, req *http.Request) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.Header().Set("Access-Control-Allow-Origin", "*")
+	err := json.NewEncoder(rw).Encode(r.State())
+	if err != nil {
+		r.log.Logf("json Encode: %s", err)
+	}
+}
@@ -0,0 +1,269 @@
+// Package relay republishes the events of a stats service as JSON, for web overlays and other browser clients.
+//
+// A Relay holds a single connection to the stats service, and serves any number of clients with Server-Sent Events
+// or websockets.  Each message is a JSON object with a type of "event" or "state":
+//
+//	{"type":"event","seq":12,"time":"2018-10-20T09:52:10.5-07:00","key":"playerKill","event":{"X":750,...}}
+//	{"type":"state","state":{"in_game":true,"map":"map_day",...}}
+//
+// A state message, with a snapshot of the game, follows every event.  Clients that connect mid-game are sent
+// the current state, and then each event of the game so far with "replay" set, before any live events.
+// Alive events are answered by the Relay, and aren't republished.
+package relay
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rickyninja/kqstat"
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/record"
+)
+
+// peerBuffer is how many messages may be queued for a client before it's considered too slow, and disconnected.
+const peerBuffer = 512
+
+// Logger is used to log connection problems.
+type Logger interface {
+	Logf(format string, a ...interface{})
+}
+
+// Message is a message sent to clients.
+type Message struct {
+	// Type is "event" or "state".
+	Type string `json:"type"`
+	// Seq is the position of an event among those the Relay has published, starting at 1.
+	Seq uint64 `json:"seq,omitempty"`
+	// Time is when an event was received.
+	Time *time.Time `json:"time,omitempty"`
+	// Key is an event's key, such as playerKill.
+	Key string `json:"key,omitempty"`
+	// Event is the parsed event.
+	Event event.Event `json:"event,omitempty"`
+	// Replay is set for events of the current game that were received before the client connected.
+	Replay bool `json:"replay,omitempty"`
+	// State is a snapshot of the game.
+	State *State `json:"state,omitempty"`
+}
+
+// Relay republishes events to any number of clients.
+type Relay struct {
+	// Sets groups games into sets, so the set score can be included in the game state.
+	// It's nil by default, and may be set before publishing any events.
+	Sets     *game.SetTracker
+	splitter game.Splitter
+	mutex    *sync.Mutex
+	seq      uint64
+	// current are the event messages of the current game, or the last one once it's over.
+	current []Message
+	stats   *game.Stats
+	inGame  bool
+	snail   *Snail
+	peers   map[chan Message]struct{}
+	log     Logger
+}
+
+// New constructs a *Relay with no clients.
+func New(l Logger) *Relay {
+	return &Relay{
+		mutex: new(sync.Mutex),
+		stats: game.NewStats(),
+		peers: make(map[chan Message]struct{}),
+		log:   l,
+	}
+}
+
+// Run connects to the stats service at addr and publishes its events, reconnecting whenever the connection is lost,
+// until stop is closed.
+func (r *Relay) Run(addr string, retry time.Duration, stop <-chan struct{}) {
+	for {
+		r.session(addr, stop)
+		select {
+		case <-stop:
+			return
+		case <-time.After(retry):
+		}
+	}
+}
+
+// session publishes events from a single connection to the stats service, until it's lost or stop is closed.
+func (r *Relay) session(addr string, stop <-chan struct{}) {
+	cl, err := kqstat.NewClient(addr, r.log)
+	if err != nil {
+		r.log.Logf("Failed to connect to %s: %s", addr, err)
+		return
+	}
+	defer cl.Conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-stop:
+			cl.Close()
+			cl.Conn.Close()
+		case <-done:
+		}
+	}()
+	err = r.Follow(cl)
+	select {
+	case <-stop:
+	default:
+		r.log.Logf("Lost connection to %s: %s", addr, err)
+	}
+}
+
+// Follow publishes each event read from src as it arrives, until reading fails, and returns why.  src is typically a
+// *kqstat.Client, but an *event.Reader replays a log.  Events that fail to parse are logged and skipped.
+func (r *Relay) Follow(src event.Source) error {
+	for {
+		line, ev, err := src.GetRawEvent()
+		if line == "" {
+			return err
+		}
+		if err != nil {
+			r.log.Logf("%s", err)
+			continue
+		}
+		r.Publish(time.Now(), ev)
+	}
+}
+
+// Publish sends an event received at t to every client, followed by the updated game state.
+func (r *Relay) Publish(t time.Time, ev event.Event) {
+	switch ev.(type) {
+	case nil, event.Alive:
+		return
+	}
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.seq++
+	m := Message{Type: "event", Seq: r.seq, Key: event.Key(ev), Event: ev}
+	if !t.IsZero() {
+		m.Time = &t
+	}
+	r.track(m)
+	if r.Sets != nil {
+		if seg, ok := r.splitter.Add(record.Envelope{Seq: m.Seq, Time: t, Event: ev}); ok {
+			r.Sets.Add(seg)
+		}
+	}
+	st := r.state()
+	r.send(m)
+	r.send(Message{Type: "state", State: &st})
+}
+
+// track updates the current game with an event message.
+func (r *Relay) track(m Message) {
+	switch m.Event.(type) {
+	case event.PlayerNames:
+		r.reset()
+	case event.GameStart:
+		// A game begins with the playernames event just before its gamestart, when there is one.
+		n := len(r.current)
+		preceded := !r.inGame && n > 0
+		if preceded {
+			_, preceded = r.current[n-1].Event.(event.PlayerNames)
+		}
+		if !preceded {
+			r.reset()
+		}
+		r.inGame = true
+	case event.Victory:
+		r.inGame = false
+	default:
+		if !r.inGame {
+			// Events between games aren't part of any game.
+			return
+		}
+	}
+	r.current = append(r.current, m)
+	r.stats.Add(m.Event)
+	r.trackSnail(m.Event)
+}
+
+// trackSnail updates the position of the snail.
+func (r *Relay) trackSnail(ev event.Event) {
+	switch v := ev.(type) {
+	case event.GetOnSnail:
+		r.snail = &Snail{X: v.X, Rider: v.Who.String(), Team: v.Who.Team()}
+	case event.SnailEat:
+		r.snail = &Snail{X: v.X, Rider: v.Rider.String(), Team: v.Rider.Team()}
+	case event.GetOffSnail:
+		r.snail = &Snail{X: v.X, Team: v.Who.Team()}
+	case event.PlayerKill:
+		// Riders killed on the snail don't get a getOffSnail event.
+		if r.snail != nil && r.snail.Rider == v.Slain.String() {
+			r.snail = &Snail{X: r.snail.X, Team: r.snail.Team}
+		}
+	}
+}
+
+// reset starts tracking a new game.
+func (r *Relay) reset() {
+	r.current = nil
+	r.stats = game.NewStats()
+	r.inGame = false
+	r.snail = nil
+}
+
+// send sends a message to every client.
+func (r *Relay) send(m Message) {
+	for p := range r.peers {
+		select {
+		case p <- m:
+		default:
+			r.log.Logf("Client is too slow, disconnecting.")
+			delete(r.peers, p)
+			close(p)
+		}
+	}
+}
+
+// State returns a snapshot of the game in progress, or of the last game once it's over.
+func (r *Relay) State() State {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.state()
+}
+
+// state returns a snapshot of the game, and must be called with the mutex held.
+func (r *Relay) state() State {
+	st := newState(r.stats, r.inGame)
+	if r.snail != nil {
+		snail := *r.snail
+		st.Snail = &snail
+	}
+	if r.Sets != nil {
+		if cur := r.Sets.Current(); cur != nil {
+			st.Set = newSet(cur, r.Sets.BestOf)
+		}
+	}
+	return st
+}
+
+// subscribe registers a new client, and returns the channel it receives messages on.
+// The channel starts with the current state and a replay of the current game.
+func (r *Relay) subscribe() chan Message {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	p := make(chan Message, peerBuffer+len(r.current)+1)
+	st := r.state()
+	p <- Message{Type: "state", State: &st}
+	for _, m := range r.current {
+		m.Replay = true
+		p <- m
+	}
+	r.peers[p] = struct{}{}
+	return p
+}
+
+// unsubscribe removes a client, unless send already removed it.
+func (r *Relay) unsubscribe(p chan Message) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.peers[p]; ok {
+		delete(r.peers, p)
+		close(p)
+	}
+}
@@ -0,0 +1,294 @@
+package relay_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/internal/testutil"
+	"github.com/rickyninja/kqstat/mock/kqstatd"
+	"github.com/rickyninja/kqstat/record"
+	"github.com/rickyninja/kqstat/relay"
+)
+
+// message is a relay.Message with the event left undecoded.
+type message struct {
+	Type   string          `json:"type"`
+	Seq    uint64          `json:"seq"`
+	Key    string          `json:"key"`
+	Event  json.RawMessage `json:"event"`
+	Replay bool            `json:"replay"`
+	State  *relay.State    `json:"state"`
+}
+
+// midGame publishes the start of a game with a kill.
+func midGame(r *relay.Relay) {
+	now := time.Now()
+	r.Publish(now, event.PlayerNames{"Kim", "Lee"})
+	r.Publish(now, event.GameStart{Map: event.Day, Orientation: event.BlueOnLeft})
+	r.Publish(now, event.Alive{Time: "8:00:00 PM"})
+	r.Publish(now, event.PlayerKill{X: 750, Y: 861, Slayer: event.GoldQueen, Slain: event.BlueChecks, SlainClass: event.Worker})
+}
+
+func TestRelay_WebSocket(t *testing.T) {
+	r := relay.New(testutil.NopLogger{})
+	midGame(r)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	read := func() message {
+		t.Helper()
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var m message
+		err := ws.ReadJSON(&m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+
+	m := read()
+	if m.Type != "state" || m.State == nil {
+		t.Fatalf("got %+v first want state", m)
+	}
+	if !m.State.InGame || m.State.Map != event.Day || m.State.Gold.Kills != 1 || m.State.Players[0].Name != "Kim" {
+		t.Errorf("wrong state %+v", m.State)
+	}
+	for _, key := range []string{"playernames", "gamestart", "playerKill"} {
+		m := read()
+		if m.Type != "event" || m.Key != key || !m.Replay {
+			t.Errorf("got %+v want replay of %s", m, key)
+		}
+	}
+
+	r.Publish(time.Now(), event.Victory{Team: event.Gold, Type: event.Military})
+	m = read()
+	if m.Type != "event" || m.Key != "victory" || m.Replay || m.Seq != 4 {
+		t.Errorf("got %+v want live victory with seq 4, since alive events aren't relayed", m)
+	}
+	var v event.Victory
+	err = json.Unmarshal(m.Event, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Team != event.Gold {
+		t.Errorf("got victory %+v", v)
+	}
+	m = read()
+	if m.Type != "state" || m.State.InGame || m.State.Victory == nil || m.State.Victory.Type != event.Military {
+		t.Errorf("got %+v want state after victory", m)
+	}
+}
+
+func TestRelay_SSE(t *testing.T) {
+	r := relay.New(testutil.NopLogger{})
+	midGame(r)
+	srv := httptest.NewServer(r.Handler())
+	defer srv.Close()
+	resp, err := http.Get(srv.URL + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("got content type %s", ct)
+	}
+	br := bufio.NewReader(resp.Body)
+	readEvent := func() (name string, m message) {
+		t.Helper()
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				t.Fatal(err)
+			}
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case line == "":
+				return name, m
+			case strings.HasPrefix(line, "event: "):
+				name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &m)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+	name, m := readEvent()
+	if name != "state" || m.State == nil || !m.State.InGame {
+		t.Errorf("got %s %+v first want state", name, m)
+	}
+	for i := 0; i < 3; i++ {
+		name, m := readEvent()
+		if name != "event" || !m.Replay {
+			t.Errorf("got %s %+v want replayed event", name, m)
+		}
+	}
+	r.Publish(time.Now(), event.BerryDeposit{Who: event.BlueAbs})
+	name, m = readEvent()
+	if name != "event" || m.Key != "berryDeposit" || m.Replay {
+		t.Errorf("got %s %+v want live berryDeposit", name, m)
+	}
+}
+
+func TestRelay_Games(t *testing.T) {
+	fd, err := os.Open(testutil.BB3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	r := relay.New(testutil.NopLogger{})
+	r.Sets = game.NewSetTracker(3, event.Day, event.Night, event.Dusk)
+	rd := record.NewReader(fd)
+	games, snails := 0, 0
+	for {
+		env, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Publish(env.Time, env.Event)
+		switch env.Event.(type) {
+		case event.GameStart:
+			if !r.State().InGame {
+				t.Fatalf("not in game after gamestart at seq %d", env.Seq)
+			}
+			games++
+		case event.GetOnSnail:
+			if st := r.State(); st.Snail == nil || st.Snail.Rider == "" {
+				t.Fatalf("no snail rider after getOnSnail at seq %d", env.Seq)
+			}
+			snails++
+		case event.Victory:
+			st := r.State()
+			if st.InGame || st.Victory == nil {
+				t.Fatalf("in game after victory at seq %d", env.Seq)
+			}
+			// The first game began before the recording did, so it isn't part of a set.
+			if games > 0 && (st.Set == nil || st.Set.Games < 1 || st.Set.BestOf != 3) {
+				t.Fatalf("got set %+v after victory at seq %d", st.Set, env.Seq)
+			}
+		}
+	}
+	if games != 17 {
+		t.Errorf("got %d games want 17", games)
+	}
+	if snails == 0 {
+		t.Error("the snail was never ridden")
+	}
+	// The last set of the recording was three games long.
+	if st := r.State(); st.Set.Games != 3 || st.Set.Wins[0]+st.Set.Wins[1] != 3 {
+		t.Errorf("got last set %+v", st.Set)
+	}
+}
+
+func TestRelay_Follow(t *testing.T) {
+	fd, err := os.Open(testutil.BB3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	logs := new(logRecorder)
+	r := relay.New(logs)
+	r.Sets = game.NewSetTracker(3, event.Day, event.Night, event.Dusk)
+	// A malformed line is logged and skipped, as it would be from a live cabinet.
+	src := event.NewReader(io.MultiReader(fd, strings.NewReader("![k[carryFood],v[x]]!\n")))
+	if err := r.Follow(src); err != io.EOF {
+		t.Errorf("got %v want io.EOF", err)
+	}
+	if len(logs.msgs) != 1 || !strings.Contains(logs.msgs[0], "line 5029: carryFood") {
+		t.Errorf("got logs %q", logs.msgs)
+	}
+	// The last set of the log was three games long.
+	if st := r.State(); st.Set == nil || st.Set.Games != 3 || st.InGame {
+		t.Errorf("got state %+v", st)
+	}
+}
+
+func TestRelay_Snail(t *testing.T) {
+	r := relay.New(testutil.NopLogger{})
+	now := time.Now()
+	r.Publish(now, event.GameStart{Map: event.Dusk})
+	if r.State().Snail != nil {
+		t.Error("snail before it was ridden")
+	}
+	r.Publish(now, event.GetOnSnail{X: 960, Y: 11, Who: event.BlueSkulls})
+	r.Publish(now, event.SnailEat{X: 900, Y: 11, Rider: event.BlueSkulls, Meal: event.GoldAbs})
+	st := r.State()
+	if st.Snail == nil || st.Snail.X != 900 || st.Snail.Rider != "blue-skulls" || st.Snail.Team != event.Blue {
+		t.Errorf("got snail %+v", st.Snail)
+	}
+	r.Publish(now, event.PlayerKill{X: 880, Y: 20, Slayer: event.GoldQueen, Slain: event.BlueSkulls, SlainClass: event.Worker})
+	st = r.State()
+	if st.Snail == nil || st.Snail.X != 900 || st.Snail.Rider != "" {
+		t.Errorf("got snail %+v after its rider was killed", st.Snail)
+	}
+	r.Publish(now, event.GameStart{Map: event.Day})
+	if r.State().Snail != nil {
+		t.Error("snail carried over to the next game")
+	}
+}
+
+func TestRelay_Run(t *testing.T) {
+	sc := kqstatd.NewScenario(testutil.NopLogger{}).
+		SendEvent(0, "gamestart", "map_night", "False", "0", "False").
+		KeepAlive(0, time.Second).
+		SendEvent(0, "berryDeposit", "960", "500", "3").
+		Expect(time.Second, "never sent")
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go http.Serve(l, sc)
+	r := relay.New(testutil.NopLogger{})
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.Run(l.Addr().String(), time.Minute, stop)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st := r.State()
+		if st.InGame && st.Map == event.Night && st.Gold.Berries == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got state %+v", st)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case res := <-sc.Results():
+		if len(res.Failures) != 1 || !strings.Contains(res.Failures[0], "never sent") {
+			t.Errorf("relay didn't reply to the keep alive: %v", res.Failures)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("scenario didn't finish")
+	}
+}
+
+// logRecorder keeps log output for a test to check.
+type logRecorder struct {
+	msgs []string
+}
+
+func (l *logRecorder) Logf(format string, a ...interface{}) {
+	l.msgs = append(l.msgs, fmt.Sprintf(format, a...))
+}
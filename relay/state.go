@@ -0,0 +1,131 @@
+package relay
+
+import (
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/game"
+)
+
+// State is a snapshot of the game in progress, or of the last game once it's over.
+type State struct {
+	// InGame reports if a game is in progress.
+	InGame bool `json:"in_game"`
+	// Map is which map the game is played on.
+	Map event.Map `json:"map,omitempty"`
+	// Orientation is how the cabs are positioned next to each other.
+	Orientation event.CabOrientation `json:"orientation,omitempty"`
+	// Players are the totals of each player, indexed by their Bee minus one.
+	Players [10]Player `json:"players"`
+	// Gold and Blue are the totals of each team.
+	Gold Team `json:"gold"`
+	Blue Team `json:"blue"`
+	// Victory is how the last game was won, and is nil while it's in progress.
+	Victory *event.Victory `json:"victory,omitempty"`
+	// Snail is where the snail is, and is nil until it has been ridden.
+	Snail *Snail `json:"snail,omitempty"`
+	// Set is the score of the current set, and is nil when the Relay doesn't track sets.
+	Set *Set `json:"set,omitempty"`
+}
+
+// Snail is the position of the snail, along with who's riding it.
+type Snail struct {
+	// X is the snail's x coordinate.  Maps are 1920 pixels wide, and the snail starts in the middle.
+	X int `json:"x"`
+	// Rider is the position of the player riding the snail, and is empty when nobody is.
+	Rider string `json:"rider,omitempty"`
+	// Team is the team of the rider, or of the last rider when nobody is riding.
+	Team event.Team `json:"team,omitempty"`
+}
+
+// Set is the score of a set.
+type Set struct {
+	// Sides are the player names of each side.  Side 0 played gold in the first game of the set.
+	Sides [2][]string `json:"sides"`
+	// Wins is how many games each side has won.
+	Wins [2]int `json:"wins"`
+	// Games is how many games of the set have been played.
+	Games int `json:"games"`
+	// BestOf is the length of the set.
+	BestOf int `json:"best_of"`
+	// GoldSide is the index into Sides of the side that played gold in the last game.
+	GoldSide int `json:"gold_side"`
+	// Winner is the index into Sides of the side that won the set, or -1 until one has.
+	Winner int `json:"winner"`
+}
+
+// Player is a player's totals for the game.
+type Player struct {
+	Position      string `json:"position"`
+	Name          string `json:"name,omitempty"`
+	Kills         int    `json:"kills"`
+	Deaths        int    `json:"deaths"`
+	QueenKills    int    `json:"queen_kills"`
+	Berries       int    `json:"berries"`
+	KickIns       int    `json:"kick_ins"`
+	SnailDistance int    `json:"snail_distance"`
+}
+
+// Team is a team's totals for the game.
+type Team struct {
+	Kills       int `json:"kills"`
+	Berries     int `json:"berries"`
+	KickIns     int `json:"kick_ins"`
+	QueenDeaths int `json:"queen_deaths"`
+	Gates       int `json:"gates"`
+}
+
+// newState returns a snapshot of a game's stats.
+func newState(s *game.Stats, inGame bool) State {
+	st := State{
+		InGame:      inGame,
+		Map:         s.Map,
+		Orientation: s.Orientation,
+		Gold:        newTeam(s, event.Gold),
+		Blue:        newTeam(s, event.Blue),
+	}
+	for i, p := range s.Players {
+		st.Players[i] = Player{
+			Position:      p.Bee.String(),
+			Name:          p.Name,
+			Kills:         p.Kills,
+			Deaths:        p.Deaths,
+			QueenKills:    p.QueenKills,
+			Berries:       p.Berries,
+			KickIns:       p.KickIns,
+			SnailDistance: p.SnailDistance,
+		}
+	}
+	if s.Victory.Team != "" {
+		v := s.Victory
+		st.Victory = &v
+	}
+	return st
+}
+
+// newSet returns the score of a set.
+func newSet(s *game.Set, bestOf int) *Set {
+	set := &Set{
+		Sides:  s.Sides,
+		Wins:   s.Wins,
+		Games:  len(s.Games),
+		BestOf: bestOf,
+		Winner: s.Winner,
+	}
+	if n := len(s.Games); n > 0 {
+		set.GoldSide = s.Games[n-1].GoldSide
+	}
+	return set
+}
+
+// newTeam returns the totals of a team.
+func newTeam(s *game.Stats, t event.Team) Team {
+	tm := Team{
+		QueenDeaths: s.QueenDeaths(t),
+		Gates:       s.Gates(t),
+	}
+	for _, p := range s.Team(t) {
+		tm.Kills += p.Kills
+		tm.Berries += p.Berries
+		tm.KickIns += p.KickIns
+	}
+	return tm
+}
@@ -0,0 +1,170 @@
+// kqstore saves Killerqueen events, games, sets and player stats to a SQLite database.
+//
+// Recordings named on the command line are ingested, or when there are none, events are saved
+// live from a stats service.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"time"
+
+	"github.com/rickyninja/kqstat"
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/player"
+	"github.com/rickyninja/kqstat/record"
+	"github.com/rickyninja/kqstat/storage"
+)
+
+func main() {
+	logger := newMylog(log.New(os.Stderr, "", log.LstdFlags))
+	var (
+		db       string
+		host     string
+		port     string
+		roster   string
+		bestOf   int
+		rotation string
+		retry    time.Duration
+		realOnly bool
+		header   record.Header
+	)
+	flag.StringVar(&db, "db", "kqstat.db", "SQLite database to save to, created if needed")
+	flag.StringVar(&host, "host", "localhost", "Killerqueen stats service host, when no recordings are given")
+	flag.StringVar(&port, "port", "12749", "Killerqueen stats service port, when no recordings are given")
+	flag.StringVar(&roster, "roster", "", "roster file mapping names to players")
+	flag.IntVar(&bestOf, "best-of", 3, "number of games in a set")
+	flag.StringVar(&rotation, "rotation", "", "comma separated maps played in each set, e.g. day,night,dusk")
+	flag.StringVar(&header.Cabinet, "cabinet", "", "name of the cabinet, overriding the one in each recording")
+	flag.StringVar(&header.Scene, "scene", "", "name of the scene, overriding the one in each recording")
+	flag.BoolVar(&realOnly, "real-only", false, "drop demo and all AI games")
+	flag.DurationVar(&retry, "retry", 5*time.Second, "how long to wait before reconnecting to the stats service")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [recording...]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var ro *player.Roster
+	if roster != "" {
+		var err error
+		ro, err = player.LoadRoster(roster)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+	reg, err := player.NewRegistry(ro)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	maps, err := game.ParseRotation(rotation)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	sets := game.NewSetTracker(bestOf, maps...)
+	store, err := storage.Open(db)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	defer store.Close()
+
+	in := store.NewIngester(header, reg, sets)
+	in.RealOnly = realOnly
+	if flag.NArg() == 0 {
+		tail(logger, in, net.JoinHostPort(host, port), retry)
+		return
+	}
+	for _, path := range flag.Args() {
+		n, err := ingestFile(in, path, header)
+		if err != nil {
+			logger.Fatalf("%s: %s", path, err)
+		}
+		logger.Printf("Saved %d events from %s", n, path)
+	}
+}
+
+// ingestFile saves every event of the recording at path, and returns how many there were.
+// The cabinet and scene in h override the ones in the recording when set.
+func ingestFile(in *storage.Ingester, path string, h record.Header) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	r := record.NewReader(f)
+	n := 0
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return n, err
+		}
+		fh := r.Header()
+		if h.Cabinet != "" {
+			fh.Cabinet = h.Cabinet
+		}
+		if h.Scene != "" {
+			fh.Scene = h.Scene
+		}
+		in.SetHeader(fh)
+		err = in.Add(env)
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, in.Flush()
+}
+
+// tail saves events from the stats service at addr until killed, reconnecting whenever the connection is lost.
+func tail(logger *mylog, in *storage.Ingester, addr string, retry time.Duration) {
+	var seq uint64
+	for {
+		cl, err := kqstat.NewClient(addr, logger)
+		if err != nil {
+			logger.Printf("Failed to connect to %s: %s", addr, err)
+			time.Sleep(retry)
+			continue
+		}
+		logger.Printf("Connected to %s", addr)
+		for {
+			line, ev, err := cl.GetRawEvent()
+			if line == "" {
+				logger.Printf("GetRawEvent: %s", err)
+				break
+			}
+			if err != nil {
+				logger.Printf("%s", err)
+			}
+			seq++
+			err = in.Add(record.Envelope{Seq: seq, Time: time.Now(), Line: line, Event: ev, Err: err})
+			if err != nil {
+				logger.Fatal(err)
+			}
+		}
+		cl.Conn.Close()
+		err = in.Flush()
+		if err != nil {
+			logger.Fatal(err)
+		}
+		time.Sleep(retry)
+	}
+}
+
+type mylog struct {
+	*log.Logger
+}
+
+func newMylog(l *log.Logger) *mylog {
+	return &mylog{l}
+}
+
+func (l *mylog) Logf(format string, a ...interface{}) {
+	l.Printf(format, a...)
+}
@@ -0,0 +1,222 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/player"
+	"github.com/rickyninja/kqstat/record"
+)
+
+// DefaultCommitInterval is how long an Ingester's writes wait to be committed while no game completes.
+const DefaultCommitInterval = 5 * time.Second
+
+// Ingester writes a stream of events to a Store, along with the games and sets they make up.
+// Writes are committed at the end of each game, once CommitInterval has passed since the last commit, and by Flush,
+// so a cabinet that sits idle between games doesn't hold the database's write lock.
+type Ingester struct {
+	// RealOnly drops demo and all AI games, along with their events, as classified by game.Segment.Kind.
+	// Dropped games don't count towards sets, and their events may be committed before they're dropped.
+	RealOnly bool
+	// CommitInterval is how long writes wait to be committed while no game completes.
+	// NewIngester sets it to DefaultCommitInterval.
+	CommitInterval time.Duration
+	store          *Store
+	header         record.Header
+	registry       *player.Registry
+	sets           *game.SetTracker
+	splitter       game.Splitter
+	tx             *sql.Tx
+	// began is when tx began.
+	began time.Time
+	// pending are the IDs of the events that may still become part of a game.
+	pending []int64
+	setIDs  map[*game.Set]int64
+}
+
+// NewIngester returns an *Ingester saving events from the cabinet and scene named in h.
+// Players are identified by registry, and games are grouped into sets by sets.
+func (s *Store) NewIngester(h record.Header, registry *player.Registry, sets *game.SetTracker) *Ingester {
+	return &Ingester{
+		CommitInterval: DefaultCommitInterval,
+		store:          s,
+		header:         h,
+		registry:       registry,
+		sets:           sets,
+		setIDs:         make(map[*game.Set]int64),
+	}
+}
+
+// SetHeader changes the cabinet and scene that the events that follow are saved with.
+func (in *Ingester) SetHeader(h record.Header) {
+	in.header = h
+}
+
+// Add saves the next event.  When it completes a game, the game, its set and its players' stats are saved too.
+func (in *Ingester) Add(env record.Envelope) error {
+	if in.tx == nil {
+		tx, err := in.store.db.Begin()
+		if err != nil {
+			return err
+		}
+		in.tx = tx
+		in.began = time.Now()
+	}
+	key := event.Key(env.Event)
+	res, err := in.tx.Exec(`INSERT INTO events (cabinet, seq, received, key, line) VALUES (?, ?, ?, ?, ?)`,
+		in.header.Cabinet, env.Seq, timestamp(env.Time), key, env.Line)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	in.pending = append(in.pending, id)
+	seg, ok := in.splitter.Add(env)
+	if !ok {
+		in.pending = in.pending[len(in.pending)-in.splitter.Pending():]
+		if time.Since(in.began) >= in.CommitInterval {
+			return in.Flush()
+		}
+		return nil
+	}
+	// A game's events are always the most recent ones received.
+	ids := in.pending[len(in.pending)-len(seg.Events):]
+	in.pending = nil
+	if in.RealOnly && seg.Kind() != game.Real {
+		err = in.execIDs(`DELETE FROM events WHERE id IN (%s)`, nil, ids)
+		if err != nil {
+			return err
+		}
+		return in.Flush()
+	}
+	err = in.saveGame(seg, ids)
+	if err != nil {
+		return err
+	}
+	return in.Flush()
+}
+
+// Flush commits everything saved so far.
+func (in *Ingester) Flush() error {
+	if in.tx == nil {
+		return nil
+	}
+	err := in.tx.Commit()
+	in.tx = nil
+	return err
+}
+
+// saveGame saves a completed game, whose events have the given IDs.
+func (in *Ingester) saveGame(seg game.Segment, ids []int64) error {
+	set := in.sets.Add(seg)
+	setID, err := in.saveSet(set)
+	if err != nil {
+		return err
+	}
+	sg := set.Games[len(set.Games)-1]
+	res, err := in.tx.Exec(`INSERT INTO games
+		(set_id, set_game, cabinet, scene, started, map, orientation, duration_ms, winner, win_condition, gold_side, kind)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		setID, len(set.Games), in.header.Cabinet, in.header.Scene, timestamp(seg.Events[0].Time),
+		string(sg.Map), string(sg.Orientation), int64(seg.End.Duration/time.Millisecond),
+		string(seg.Victory.Team), string(seg.Victory.Type), sg.GoldSide, seg.Kind().String())
+	if err != nil {
+		return err
+	}
+	gameID, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	err = in.execIDs(`UPDATE events SET game_id = ? WHERE id IN (%s)`, []interface{}{gameID}, ids)
+	if err != nil {
+		return err
+	}
+	stats := game.Summarize(seg)
+	for i, ps := range stats.Players {
+		var playerID interface{}
+		if i < len(seg.Names) {
+			if p := in.registry.Resolve(seg.Names[i]); p.Known() {
+				_, err := in.tx.Exec(`INSERT INTO players (id, name) VALUES (?, ?)
+					ON CONFLICT (id) DO UPDATE SET name = excluded.name`, p.ID, p.Name)
+				if err != nil {
+					return err
+				}
+				playerID = p.ID
+			}
+		}
+		team := ps.Bee.Team()
+		_, err := in.tx.Exec(`INSERT INTO game_players
+			(game_id, position, player_id, team, won, kills, deaths, queen_kills, berries, kick_ins, snail_distance)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			gameID, int(ps.Bee), playerID, string(team), team == seg.Victory.Team,
+			ps.Kills, ps.Deaths, ps.QueenKills, ps.Berries, ps.KickIns, ps.SnailDistance)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// saveSet inserts or updates a set, and returns its ID.
+func (in *Ingester) saveSet(set *game.Set) (int64, error) {
+	var winner interface{}
+	if set.Winner >= 0 {
+		winner = set.Winner
+	}
+	if id, ok := in.setIDs[set]; ok {
+		_, err := in.tx.Exec(`UPDATE sets SET side0_wins = ?, side1_wins = ?, winner_side = ? WHERE id = ?`,
+			set.Wins[0], set.Wins[1], winner, id)
+		return id, err
+	}
+	res, err := in.tx.Exec(`INSERT INTO sets (cabinet, best_of, side0_wins, side1_wins, winner_side) VALUES (?, ?, ?, ?, ?)`,
+		in.header.Cabinet, in.sets.BestOf, set.Wins[0], set.Wins[1], winner)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	in.setIDs[set] = id
+	return id, nil
+}
+
+// maxIDs is how many IDs execIDs passes to a statement at once, which is well under SQLite's limit on parameters.
+const maxIDs = 500
+
+// execIDs runs query for ids, in batches of up to maxIDs.  The %s in query is replaced by a parameter for each ID
+// of the batch, which follow args.  IDs are listed rather than given as a range, since another writer to the
+// database may have inserted events between them.
+func (in *Ingester) execIDs(query string, args []interface{}, ids []int64) error {
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > maxIDs {
+			batch = batch[:maxIDs]
+		}
+		ids = ids[len(batch):]
+		params := append([]interface{}(nil), args...)
+		for _, id := range batch {
+			params = append(params, id)
+		}
+		marks := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+		_, err := in.tx.Exec(fmt.Sprintf(query, marks), params...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timestamp formats t for storage, or returns nil for the zero time.
+func timestamp(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,142 @@
+// Package storage saves events, games, sets, players and their stats in a SQLite database.
+//
+// It uses a pure Go SQLite driver, so it works without cgo.  It's a separate module from kqstat, so that the
+// driver's dependencies aren't required by users of the rest of kqstat.
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	// Registers the "sqlite" database/sql driver.
+	_ "modernc.org/sqlite"
+)
+
+// migrations are the statements that bring the schema from each version to the next.
+// The schema version is the number of migrations applied, so existing migrations must never change.
+var migrations = []string{
+	`CREATE TABLE players (
+		id   TEXT PRIMARY KEY,
+		name TEXT NOT NULL
+	);
+	CREATE TABLE sets (
+		id          INTEGER PRIMARY KEY,
+		cabinet     TEXT NOT NULL,
+		best_of     INTEGER NOT NULL,
+		side0_wins  INTEGER NOT NULL,
+		side1_wins  INTEGER NOT NULL,
+		winner_side INTEGER
+	);
+	CREATE TABLE games (
+		id            INTEGER PRIMARY KEY,
+		set_id        INTEGER REFERENCES sets(id),
+		set_game      INTEGER,
+		cabinet       TEXT NOT NULL,
+		scene         TEXT NOT NULL,
+		started       TEXT,
+		map           TEXT NOT NULL,
+		orientation   TEXT NOT NULL,
+		duration_ms   INTEGER NOT NULL,
+		winner        TEXT NOT NULL,
+		win_condition TEXT NOT NULL,
+		gold_side     INTEGER
+	);
+	CREATE TABLE events (
+		id       INTEGER PRIMARY KEY,
+		game_id  INTEGER REFERENCES games(id),
+		cabinet  TEXT NOT NULL,
+		seq      INTEGER NOT NULL,
+		received TEXT,
+		key      TEXT NOT NULL,
+		line     TEXT NOT NULL
+	);
+	CREATE INDEX events_game_id ON events(game_id);
+	CREATE TABLE game_players (
+		game_id        INTEGER NOT NULL REFERENCES games(id),
+		position       INTEGER NOT NULL,
+		player_id      TEXT REFERENCES players(id),
+		team           TEXT NOT NULL,
+		won            INTEGER NOT NULL,
+		kills          INTEGER NOT NULL,
+		deaths         INTEGER NOT NULL,
+		queen_kills    INTEGER NOT NULL,
+		berries        INTEGER NOT NULL,
+		kick_ins       INTEGER NOT NULL,
+		snail_distance INTEGER NOT NULL,
+		PRIMARY KEY (game_id, position)
+	);
+	CREATE INDEX game_players_player_id ON game_players(player_id);`,
+	`ALTER TABLE games ADD COLUMN kind TEXT NOT NULL DEFAULT 'real';`,
+}
+
+// Store is a SQLite database of games.
+type Store struct {
+	db *sql.DB
+}
+
+// Open opens the database at path, creating it if needed, and migrates it to the latest schema version.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer, so sharing one connection avoids busy errors between them.
+	db.SetMaxOpenConns(1)
+	s := &Store{db: db}
+	err = s.migrate()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// DB returns the underlying database, for running queries.
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Version returns the schema version of the database.
+func (s *Store) Version() (int, error) {
+	var v int
+	err := s.db.QueryRow("PRAGMA user_version").Scan(&v)
+	return v, err
+}
+
+// migrate applies each migration the database hasn't had yet.
+func (s *Store) migrate() error {
+	v, err := s.Version()
+	if err != nil {
+		return err
+	}
+	if v > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than supported version %d", v, len(migrations))
+	}
+	for ; v < len(migrations); v++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(migrations[v])
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %s", v+1, err)
+		}
+		// PRAGMA doesn't accept parameters.
+		_, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", v+1))
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rickyninja/kqstat/game"
+	"github.com/rickyninja/kqstat/internal/testutil"
+	"github.com/rickyninja/kqstat/player"
+	"github.com/rickyninja/kqstat/record"
+)
+
+func open(t *testing.T) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "kqstat.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestOpen_Migrates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kqstat.db")
+	for i := 0; i < 2; i++ {
+		s, err := Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := s.Version()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != len(migrations) {
+			t.Errorf("got version %d want %d", v, len(migrations))
+		}
+		s.Close()
+	}
+}
+
+func TestOpen_TooNew(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kqstat.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.DB().Exec("PRAGMA user_version = 1000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	s, err = Open(path)
+	if err == nil {
+		s.Close()
+		t.Fatal("expected an error opening a database from the future")
+	}
+}
+
+func TestIngester(t *testing.T) {
+	s := open(t)
+	reg, err := player.NewRegistry(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := s.NewIngester(record.Header{Cabinet: "blue"}, reg, game.NewSetTracker(3))
+	f, err := os.Open(testutil.BB3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r := record.NewReader(f)
+	n := 0
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = in.Add(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		n++
+	}
+	err = in.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := func(query string, args ...interface{}) int {
+		t.Helper()
+		var c int
+		err := s.DB().QueryRow(query, args...).Scan(&c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	if got := count("SELECT COUNT(*) FROM events"); got != n {
+		t.Errorf("got %d events want %d", got, n)
+	}
+	if got := count("SELECT COUNT(*) FROM events WHERE cabinet = ?", "blue"); got != n {
+		t.Errorf("got %d events from the blue cabinet want %d", got, n)
+	}
+	if got := count("SELECT COUNT(*) FROM games"); got != 17 {
+		t.Errorf("got %d games want 17", got)
+	}
+	if got := count("SELECT COUNT(*) FROM games WHERE kind = 'real'"); got != 17 {
+		t.Errorf("got %d real games want 17", got)
+	}
+	if got := count("SELECT COUNT(*) FROM game_players"); got != 17*10 {
+		t.Errorf("got %d game players want %d", got, 17*10)
+	}
+	// Each game includes its gamestart and victory events.
+	if got := count("SELECT COUNT(*) FROM events WHERE key = 'victory' AND game_id IS NOT NULL"); got != 17 {
+		t.Errorf("got %d victories in games want 17", got)
+	}
+	if got := count("SELECT COUNT(DISTINCT game_id) FROM events WHERE key = 'gamestart'"); got != 17 {
+		t.Errorf("got %d games with a gamestart want 17", got)
+	}
+	if got := count("SELECT COUNT(*) FROM games WHERE winner = ''"); got != 0 {
+		t.Errorf("got %d games without a winner", got)
+	}
+	// Every game has exactly one team of winners.
+	if got := count("SELECT COUNT(*) FROM game_players WHERE won"); got != 17*5 {
+		t.Errorf("got %d winning players want %d", got, 17*5)
+	}
+	sets := count("SELECT COUNT(*) FROM sets")
+	if sets < 1 || sets > 17 {
+		t.Errorf("got %d sets", sets)
+	}
+	if got := count("SELECT COUNT(*) FROM games WHERE set_id IS NULL"); got != 0 {
+		t.Errorf("got %d games outside a set", got)
+	}
+	var kills, berries int
+	err = s.DB().QueryRow("SELECT SUM(kills), SUM(berries) FROM game_players").Scan(&kills, &berries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kills == 0 || berries == 0 {
+		t.Errorf("got %d kills and %d berries, expected some of each", kills, berries)
+	}
+}
+
+func TestIngester_RealOnly(t *testing.T) {
+	s := open(t)
+	reg, err := player.NewRegistry(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := s.NewIngester(record.Header{Cabinet: "blue"}, reg, game.NewSetTracker(3))
+	in.RealOnly = true
+	r := record.NewReader(strings.NewReader(`![k[playernames],v[,,,,,,,,,]]!
+![k[gamestart],v[map_day,False,0,True]]!
+![k[playerKill],v[1190,860,8,5,Worker]]!
+![k[gameend],v[map_day,False,30.5,True]]!
+![k[victory],v[Blue,military]]!
+![k[playernames],v[a,b,c,d,e,f,g,h,i,j]]!
+![k[gamestart],v[map_night,False,0,False]]!
+![k[playerKill],v[750,861,1,2,Queen]]!
+![k[gameend],v[map_night,False,60.5,False]]!
+![k[victory],v[Gold,military]]!
+`))
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = in.Add(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var games, events int
+	err = s.DB().QueryRow("SELECT (SELECT COUNT(*) FROM games), (SELECT COUNT(*) FROM events)").Scan(&games, &events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if games != 1 || events != 5 {
+		t.Errorf("got %d games and %d events want 1 and 5", games, events)
+	}
+}
+
+func TestIngester_CommitInterval(t *testing.T) {
+	s := open(t)
+	reg, err := player.NewRegistry(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	in := s.NewIngester(record.Header{Cabinet: "blue"}, reg, game.NewSetTracker(3))
+	in.CommitInterval = 0
+	r := record.NewReader(strings.NewReader(`![k[alive],v[8:00:00 PM]]!
+![k[alive],v[8:00:05 PM]]!
+![k[playernames],v[a,b,c,d,e,f,g,h,i,j]]!
+![k[gamestart],v[map_night,False,0,False]]!
+![k[playerKill],v[750,861,1,2,Queen]]!
+![k[gameend],v[map_night,False,60.5,False]]!
+![k[victory],v[Gold,military]]!
+![k[alive],v[8:01:10 PM]]!
+`))
+	for n := 1; ; n++ {
+		env, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = in.Add(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Every event is committed right away, so the database can be read while the ingester is in use.
+		var events int
+		err = s.DB().QueryRow("SELECT COUNT(*) FROM events").Scan(&events)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if events != n {
+			t.Fatalf("got %d events committed want %d", events, n)
+		}
+	}
+	if len(in.pending) != 0 {
+		t.Errorf("got %d pending events between games want 0", len(in.pending))
+	}
+	var inGame int
+	err = s.DB().QueryRow("SELECT COUNT(*) FROM events WHERE game_id IS NOT NULL").Scan(&inGame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inGame != 5 {
+		t.Errorf("got %d events of the game want 5", inGame)
+	}
+}
+
+func TestIngester_SharedDatabase(t *testing.T) {
+	s := open(t)
+	reg, err := player.NewRegistry(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blue := s.NewIngester(record.Header{Cabinet: "blue"}, reg, game.NewSetTracker(3))
+	blue.RealOnly = true
+	blue.CommitInterval = 0
+	red := s.NewIngester(record.Header{Cabinet: "red"}, reg, game.NewSetTracker(3))
+	red.CommitInterval = 0
+	r := record.NewReader(strings.NewReader(`![k[playernames],v[,,,,,,,,,]]!
+![k[gamestart],v[map_day,False,0,True]]!
+![k[playerKill],v[1190,860,8,5,Worker]]!
+![k[gameend],v[map_day,False,30.5,True]]!
+![k[victory],v[Blue,military]]!
+![k[playernames],v[a,b,c,d,e,f,g,h,i,j]]!
+![k[gamestart],v[map_night,False,0,False]]!
+![k[playerKill],v[750,861,1,2,Queen]]!
+![k[gameend],v[map_night,False,60.5,False]]!
+![k[victory],v[Gold,military]]!
+`))
+	// Another cabinet's events are saved between each of blue's, so blue's games don't have contiguous IDs.
+	alive, err := record.NewReader(strings.NewReader("![k[alive],v[8:00:00 PM]]!\n")).Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = blue.Add(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = red.Add(alive)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var redEvents, redInGame, blueEvents, blueInGame int
+	err = s.DB().QueryRow(`SELECT
+		(SELECT COUNT(*) FROM events WHERE cabinet = 'red'),
+		(SELECT COUNT(*) FROM events WHERE cabinet = 'red' AND game_id IS NOT NULL),
+		(SELECT COUNT(*) FROM events WHERE cabinet = 'blue'),
+		(SELECT COUNT(*) FROM events WHERE cabinet = 'blue' AND game_id IS NOT NULL)`).Scan(&redEvents, &redInGame, &blueEvents, &blueInGame)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redEvents != 10 || redInGame != 0 {
+		t.Errorf("got %d red events with %d in blue's game want 10 with none", redEvents, redInGame)
+	}
+	if blueEvents != 5 || blueInGame != 5 {
+		t.Errorf("got %d blue events with %d in a game want 5 in a game", blueEvents, blueInGame)
+	}
+}
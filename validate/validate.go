@@ -0,0 +1,305 @@
+// Package validate checks a stream of events for anomalies, such as malformed lines and events that break the rules
+// of the game, which point to bugs in the stats service or a damaged recording.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/record"
+)
+
+// Kind is a type of anomaly.
+type Kind string
+
+const (
+	// Malformed lines can't be decoded or parsed.
+	Malformed Kind = "malformed"
+	// InvalidValue events parsed, but have a value that's out of range or unknown, such as player position 11.
+	InvalidValue Kind = "invalid-value"
+	// OutsideGame events happen between games, when only keep alives, player names, spawns and gates are expected.
+	OutsideGame Kind = "outside-game"
+	// UnfinishedGame games are followed by another gamestart before their victory.
+	UnfinishedGame Kind = "unfinished-game"
+	// DeadKill kills involve a queen who already died three times, which should have ended the game.
+	DeadKill Kind = "dead-kill"
+	// UnreservedGate events use or leave a gate that the player didn't enter.
+	UnreservedGate Kind = "unreserved-gate"
+	// SnailMismatch events mount, dismount or feed the snail out of turn, such as getting off without getting on.
+	SnailMismatch Kind = "snail-mismatch"
+	// VictoryWithoutEnd victories aren't preceded by their game's gameend.
+	VictoryWithoutEnd Kind = "victory-without-gameend"
+)
+
+// Anomaly is a problem found with an event.
+type Anomaly struct {
+	// Line is the line number of the event in its recording, or 0 when unknown.
+	Line int `json:"line,omitempty"`
+	// Seq is the event's sequence number in its session.
+	Seq  uint64 `json:"seq,omitempty"`
+	Kind Kind   `json:"kind"`
+	// Message describes the problem.
+	Message string `json:"message"`
+	// Text is the event text as sent by the stats service.
+	Text string `json:"text,omitempty"`
+}
+
+func (a Anomaly) String() string {
+	if a.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", a.Line, a.Kind, a.Message)
+	}
+	return fmt.Sprintf("%s: %s", a.Kind, a.Message)
+}
+
+// queenLives is how many times a queen may die before her team loses.
+const queenLives = 3
+
+// gate is where a gate is.
+type gate struct {
+	x, y int
+}
+
+// Validator checks a stream of events.
+//
+// A stream may begin partway through a game, so events before the first gamestart or victory aren't known to be
+// inside or outside a game, and game logic is only checked for games whose gamestart was seen.
+// Gates entered during a game may be used or left after its victory, until the next gamestart.
+// Workers respawn without an event, so only queens are known to be dead.
+type Validator struct {
+	// started is set once the stream is known to be inside or outside a game.
+	started bool
+	inGame  bool
+	ended   bool
+	deaths  map[event.Bee]int
+	gates   map[event.Bee]gate
+	rider   event.Bee
+}
+
+// NewValidator returns a *Validator for a new stream.
+func NewValidator() *Validator {
+	return &Validator{
+		deaths: make(map[event.Bee]int),
+		gates:  make(map[event.Bee]gate),
+	}
+}
+
+// Add checks the next event in the stream, read from line of its recording, and returns its anomalies.
+func (v *Validator) Add(line int, env record.Envelope) []Anomaly {
+	var found []Anomaly
+	report := func(k Kind, format string, a ...interface{}) {
+		found = append(found, Anomaly{Line: line, Seq: env.Seq, Kind: k, Message: fmt.Sprintf(format, a...), Text: env.Line})
+	}
+	if env.Err != nil {
+		report(Malformed, "%s", env.Err)
+		return found
+	}
+	if msg := invalid(env.Event); msg != "" {
+		report(InvalidValue, "%s", msg)
+	}
+	key := event.Key(env.Event)
+	switch ev := env.Event.(type) {
+	case event.Alive, event.PlayerNames, event.Spawn, event.BlessMaiden:
+		return found
+	case event.GameStart:
+		if v.inGame {
+			report(UnfinishedGame, "gamestart before the previous game's victory")
+		}
+		v.reset()
+		v.started, v.inGame = true, true
+		return found
+	case event.GameEnd:
+		if v.started && !v.inGame {
+			report(OutsideGame, "gameend outside a game")
+		}
+		v.ended = true
+		return found
+	case event.Victory:
+		if v.started && !v.inGame {
+			report(OutsideGame, "victory outside a game")
+		} else if v.inGame && !v.ended {
+			report(VictoryWithoutEnd, "%s won without a gameend", ev.Team)
+		}
+		// Players still in a gate when the game ends use or leave it after the victory, so keep their reservations.
+		gates := v.gates
+		v.reset()
+		v.gates = gates
+		v.started, v.inGame = true, false
+		return found
+	}
+	if !v.inGame {
+		if v.finishedGate(env.Event) {
+			return found
+		}
+		if v.started {
+			report(OutsideGame, "%s outside a game", key)
+		}
+		return found
+	}
+
+	switch ev := env.Event.(type) {
+	case event.PlayerKill:
+		for _, b := range []event.Bee{ev.Slayer, ev.Slain} {
+			if b.IsQueen() && v.deaths[b] >= queenLives {
+				report(DeadKill, "%s already died %d times", b, v.deaths[b])
+			}
+		}
+		if ev.Slain.IsQueen() {
+			v.deaths[ev.Slain]++
+		}
+		delete(v.gates, ev.Slain)
+	case event.ReserveMaiden:
+		v.gates[ev.Who] = gate{ev.X, ev.Y}
+	case event.UnreserveMaiden:
+		if g, ok := v.gates[ev.Who]; !ok || g != (gate{ev.X, ev.Y}) {
+			report(UnreservedGate, "%s left the gate at %d,%d without entering it", ev.Who, ev.X, ev.Y)
+		}
+		delete(v.gates, ev.Who)
+	case event.UseMaiden:
+		if g, ok := v.gates[ev.Who]; !ok || g != (gate{ev.X, ev.Y}) {
+			report(UnreservedGate, "%s used the gate at %d,%d without entering it", ev.Who, ev.X, ev.Y)
+		}
+		delete(v.gates, ev.Who)
+	case event.GetOnSnail:
+		if v.rider != 0 {
+			report(SnailMismatch, "%s got on the snail while %s was riding it", ev.Who, v.rider)
+		}
+		v.rider = ev.Who
+	case event.GetOffSnail:
+		if v.rider != ev.Who {
+			report(SnailMismatch, "%s got off the snail without riding it", ev.Who)
+		}
+		v.rider = 0
+	case event.SnailEat:
+		if v.rider != ev.Rider {
+			report(SnailMismatch, "%s fed the snail without riding it", ev.Rider)
+		}
+	}
+	return found
+}
+
+// finishedGate reports if ev uses or leaves a gate that was entered during the last game, which the stats service
+// sends after the game's victory for players who were still in a gate.
+func (v *Validator) finishedGate(ev event.Event) bool {
+	var who event.Bee
+	var at gate
+	switch ev := ev.(type) {
+	case event.UseMaiden:
+		who, at = ev.Who, gate{ev.X, ev.Y}
+	case event.UnreserveMaiden:
+		who, at = ev.Who, gate{ev.X, ev.Y}
+	default:
+		return false
+	}
+	if g, ok := v.gates[who]; !ok || g != at {
+		return false
+	}
+	delete(v.gates, who)
+	return true
+}
+
+// reset forgets the state of the previous game.
+func (v *Validator) reset() {
+	v.ended = false
+	v.deaths = make(map[event.Bee]int)
+	v.gates = make(map[event.Bee]gate)
+	v.rider = 0
+}
+
+// Validate checks every event read from r, and returns the anomalies found.
+// Lines of the recording that can't be decoded are reported as malformed, rather than stopping validation.
+func Validate(r *record.Reader) ([]Anomaly, error) {
+	var found []Anomaly
+	v := NewValidator()
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			return found, nil
+		}
+		var le *event.LineError
+		if errors.As(err, &le) {
+			found = append(found, Anomaly{Line: le.Line, Kind: Malformed, Message: le.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return found, err
+		}
+		found = append(found, v.Add(r.Line(), env)...)
+	}
+}
+
+// invalid describes the values of ev that are out of range or unknown, or returns an empty string when they're all valid.
+func invalid(ev event.Event) string {
+	var bees []event.Bee
+	switch v := ev.(type) {
+	case event.BerryDeposit:
+		bees = []event.Bee{v.Who}
+	case event.BerryKickIn:
+		bees = []event.Bee{v.Who}
+	case event.BlessMaiden:
+		if !v.Team.Valid() {
+			return fmt.Sprintf("unknown team %q", v.Team)
+		}
+	case event.CarryFood:
+		bees = []event.Bee{v.Who}
+	case event.GameEnd:
+		return invalidGame(v.Map, v.Orientation)
+	case event.GameStart:
+		return invalidGame(v.Map, v.Orientation)
+	case event.GetOffSnail:
+		bees = []event.Bee{v.Who}
+	case event.GetOnSnail:
+		bees = []event.Bee{v.Who}
+	case event.Glance:
+		bees = []event.Bee{v.Attacker, v.Target}
+	case event.PlayerKill:
+		if !v.SlainClass.Valid() {
+			return fmt.Sprintf("unknown class %q", v.SlainClass)
+		}
+		bees = []event.Bee{v.Slayer, v.Slain}
+	case event.PlayerNames:
+		if len(v) != 10 {
+			return fmt.Sprintf("got %d player names, want 10", len(v))
+		}
+	case event.ReserveMaiden:
+		bees = []event.Bee{v.Who}
+	case event.SnailEat:
+		bees = []event.Bee{v.Rider, v.Meal}
+	case event.SnailEscape:
+		bees = []event.Bee{v.Who}
+	case event.Spawn:
+		bees = []event.Bee{v.Who}
+	case event.UnreserveMaiden:
+		bees = []event.Bee{v.Who}
+	case event.UseMaiden:
+		if !v.Buff.Valid() {
+			return fmt.Sprintf("unknown buff %q", v.Buff)
+		}
+		bees = []event.Bee{v.Who}
+	case event.Victory:
+		if !v.Team.Valid() {
+			return fmt.Sprintf("unknown team %q", v.Team)
+		}
+		if !v.Type.Valid() {
+			return fmt.Sprintf("unknown win condition %q", v.Type)
+		}
+	}
+	for _, b := range bees {
+		if !b.Valid() {
+			return fmt.Sprintf("unknown player position %d", int(b))
+		}
+	}
+	return ""
+}
+
+// invalidGame describes an unknown map or orientation of a gamestart or gameend.
+func invalidGame(m event.Map, o event.CabOrientation) string {
+	if !m.Valid() {
+		return fmt.Sprintf("unknown map %q", m)
+	}
+	if !o.Valid() {
+		return fmt.Sprintf("unknown orientation %q", o)
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package validate
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rickyninja/kqstat/internal/testutil"
+	"github.com/rickyninja/kqstat/record"
+)
+
+func TestValidate_BB3(t *testing.T) {
+	t.Parallel()
+	f, err := os.Open(testutil.BB3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	found, err := Validate(record.NewReader(f))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Twice a gate was used just after the victory, which is fine since the player entered it during the game.
+	if len(found) != 0 {
+		t.Errorf("got %d anomalies want none: %v", len(found), found)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	log := `![k[useMaiden],v[560,260,maiden_wings,8]]!
+![k[victory],v[Gold,military]]!
+![k[carryFood],v[3]]!
+{"seq":1,"line":
+![k[playernames],v[a,b,c,d,e,f,g,h,i,j]]!
+![k[gamestart],v[map_day,False,0,False]]!
+![k[bogus],v[1]]!
+![k[carryFood],v[11]]!
+![k[carryFood],v[x]]!
+![k[reserveMaiden],v[560,260,8]]!
+![k[useMaiden],v[560,260,maiden_wings,8]]!
+![k[useMaiden],v[960,500,maiden_speed,5]]!
+![k[reserveMaiden],v[960,500,6]]!
+![k[unreserveMaiden],v[960,500,,6]]!
+![k[unreserveMaiden],v[960,500,,6]]!
+![k[getOnSnail: ],v[960,11,4]]!
+![k[getOnSnail: ],v[960,11,3]]!
+![k[snailEat],v[960,11,3,5]]!
+![k[getOffSnail: ],v[960,11,,4]]!
+![k[getOffSnail: ],v[960,11,,4]]!
+![k[playerKill],v[750,861,1,2,Queen]]!
+![k[playerKill],v[750,861,1,2,Queen]]!
+![k[playerKill],v[750,861,1,2,Queen]]!
+![k[playerKill],v[750,861,1,2,Queen]]!
+![k[playerKill],v[750,861,2,1,Queen]]!
+![k[gamestart],v[map_night,False,0,False]]!
+![k[victory],v[Blue,economic]]!
+`
+	found, err := Validate(record.NewReader(strings.NewReader(log)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		line int
+		kind Kind
+	}{
+		// The stream begins during a game, so the first useMaiden isn't outside one.
+		{3, OutsideGame},
+		{4, Malformed},
+		{7, Malformed},
+		{8, InvalidValue},
+		{9, Malformed},
+		{12, UnreservedGate},
+		{15, UnreservedGate},
+		{17, SnailMismatch},
+		{19, SnailMismatch},
+		{20, SnailMismatch},
+		{24, DeadKill},
+		{25, DeadKill},
+		{26, UnfinishedGame},
+		{27, VictoryWithoutEnd},
+	}
+	if len(found) != len(want) {
+		t.Fatalf("got %d anomalies want %d: %v", len(found), len(want), found)
+	}
+	for i, w := range want {
+		if found[i].Line != w.line || found[i].Kind != w.kind {
+			t.Errorf("anomaly %d: got %s want line %d: %s", i, found[i], w.line, w.kind)
+		}
+	}
+}
+
+func TestValidate_GateAfterVictory(t *testing.T) {
+	t.Parallel()
+	log := `![k[gamestart],v[map_day,False,0,False]]!
+![k[reserveMaiden],v[560,260,6]]!
+![k[reserveMaiden],v[1360,260,3]]!
+![k[gameend],v[map_day,False,60,False]]!
+![k[victory],v[Gold,military]]!
+![k[useMaiden],v[560,260,maiden_wings,6]]!
+![k[unreserveMaiden],v[1360,260,,3]]!
+![k[useMaiden],v[560,260,maiden_wings,6]]!
+![k[useMaiden],v[960,500,maiden_speed,5]]!
+`
+	found, err := Validate(record.NewReader(strings.NewReader(log)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Each gate entered during the game may be finished once after it.
+	if len(found) != 2 || found[0].Line != 8 || found[1].Line != 9 || found[0].Kind != OutsideGame || found[1].Kind != OutsideGame {
+		t.Errorf("got %v want lines 8 and 9 outside a game", found)
+	}
+}
@@ -0,0 +1,88 @@
+package zone
+
+import "github.com/rickyninja/kqstat/event"
+
+// gateReach is how far from a gate's center a position is considered to be at the gate.
+const gateReach = 50
+
+// builtin is the geometry of each map with blue on the left.  Zones on a side are given for blue and mirrored for gold.
+var builtin = Atlas{
+	event.Day: {Zones: concat(
+		gates(560, 260, "bottom gate", event.Wings),
+		gates(410, 860, "speed gate", event.Speed),
+		gate(960, 500, "middle gate", event.Wings),
+		sides(Zone{Name: "hive", Kind: Hive, Rect: Rect{740, 820, 900, 1000}}),
+		sides(Zone{Name: "queen spawn", Kind: QueenSpawn, Rect: Rect{620, 1000, 900, 1080}}),
+		sides(Zone{Name: "berry bowl", Kind: BerryBowl, Rect: Rect{180, 60, 380, 200}}),
+		[]Zone{
+			{Name: "berry bowl", Kind: BerryBowl, Rect: Rect{860, 60, 1060, 200}},
+			{Name: "snail track", Kind: SnailTrack, Rect: Rect{0, 0, Width, 60}},
+		},
+	)},
+	event.Night: {Zones: concat(
+		gates(700, 260, "bottom gate", event.Wings),
+		gates(170, 740, "speed gate", event.Speed),
+		gate(960, 700, "middle gate", event.Wings),
+		sides(Zone{Name: "hive", Kind: Hive, Rect: Rect{40, 0, 280, 200}}),
+		sides(Zone{Name: "queen spawn", Kind: QueenSpawn, Rect: Rect{280, 0, 480, 120}}),
+		sides(Zone{Name: "berry bowl", Kind: BerryBowl, Rect: Rect{380, 820, 580, 960}}),
+		[]Zone{
+			{Name: "berry bowl", Kind: BerryBowl, Rect: Rect{860, 180, 1060, 320}},
+			{Name: "snail track", Kind: SnailTrack, Rect: Rect{400, 460, 1520, 530}},
+		},
+	)},
+	event.Dusk: {Zones: concat(
+		gates(310, 620, "top gate", event.Wings),
+		gates(340, 140, "speed gate", event.Speed),
+		gate(960, 140, "bottom gate", event.Wings),
+		sides(Zone{Name: "hive", Kind: Hive, Rect: Rect{680, 520, 900, 780}}),
+		sides(Zone{Name: "queen spawn", Kind: QueenSpawn, Rect: Rect{680, 780, 900, 900}}),
+		sides(Zone{Name: "berry bowl", Kind: BerryBowl, Rect: Rect{80, 820, 280, 960}}),
+		[]Zone{
+			{Name: "berry bowl", Kind: BerryBowl, Rect: Rect{860, 880, 1060, 1020}},
+			{Name: "snail track", Kind: SnailTrack, Rect: Rect{0, 0, Width, 60}},
+		},
+	)},
+	event.BonusMilitary: bonus,
+	event.BonusSnail:    bonus,
+}
+
+// bonus is the geometry shared by every layout of the bonus maps.  Their gates and platforms change between layouts,
+// so they aren't zones, but the hives, queen spawns and snail track stay put.
+var bonus = Geometry{Zones: concat(
+	[]Zone{{Name: "snail track", Kind: SnailTrack, Rect: Rect{0, 0, Width, 60}}},
+	sides(Zone{Name: "hive", Kind: Hive, Rect: Rect{40, 60, 280, 260}}),
+	sides(Zone{Name: "queen spawn", Kind: QueenSpawn, Rect: Rect{620, 1000, 900, 1080}}),
+)}
+
+// gate returns a gate in the middle of a map, centered on x, y.
+func gate(x, y int, name string, buff event.Buff) []Zone {
+	return []Zone{{
+		Name: name,
+		Kind: Gate,
+		Buff: buff,
+		Rect: Rect{x - gateReach, y - gateReach, x + gateReach, y + gateReach},
+	}}
+}
+
+// gates returns a gate on each side of a map, with the blue one centered on x, y.
+func gates(x, y int, name string, buff event.Buff) []Zone {
+	return sides(gate(x, y, name, buff)[0])
+}
+
+// sides returns z on the blue side of the map, and its mirror image on the gold side.
+func sides(z Zone) []Zone {
+	blue, gold := z, z
+	blue.Side, gold.Side = event.Blue, event.Gold
+	gold.Rect = Rect{Width - z.Rect.MaxX, z.Rect.MinY, Width - z.Rect.MinX, z.Rect.MaxY}
+	return []Zone{blue, gold}
+}
+
+// concat joins lists of zones.
+func concat(lists ...[]Zone) []Zone {
+	var zones []Zone
+	for _, l := range lists {
+		zones = append(zones, l...)
+	}
+	return zones
+}
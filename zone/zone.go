@@ -0,0 +1,182 @@
+// Package zone names the areas of each map, so positions like 1220,260 can be described as "gold-side bottom gate".
+//
+// Zones are defined as though blue were on the left, which is the BLUE_ON_LEFT orientation.  The stats service
+// mirrors positions when the cabs are GOLD_ON_LEFT, so they're mirrored back before being located.
+//
+// The built-in gates and snail tracks, and the hives of Night and Dusk, were measured from recorded events.
+// The rest are estimates, since berry bowls and queen spawns aren't reported by any event, and no bonus games have
+// been recorded.  The bonus maps have hives, queen spawns and a snail track, but no gates, since those change
+// between layouts.  Zones can be corrected with ReadAtlas, and maps without geometry are only split into sides.
+package zone
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/rickyninja/kqstat/event"
+)
+
+// Width is the width of every map in the stats service's coordinate system.
+const Width = 1920
+
+// middle is how far from the center of a map a position is considered to be in the middle, rather than on a side.
+const middle = 100
+
+// Kind is the type of a zone.
+type Kind string
+
+const (
+	Hive       Kind = "hive"
+	Gate       Kind = "gate"
+	BerryBowl  Kind = "berry bowl"
+	SnailTrack Kind = "snail track"
+	QueenSpawn Kind = "queen spawn"
+	// Field is anywhere that isn't in a zone.
+	Field Kind = "field"
+)
+
+// Rect is an area of a map, including its edges.  Y increases upward.
+type Rect struct {
+	MinX int `json:"min_x"`
+	MinY int `json:"min_y"`
+	MaxX int `json:"max_x"`
+	MaxY int `json:"max_y"`
+}
+
+// Contains reports if x, y is in r.
+func (r Rect) Contains(x, y int) bool {
+	return x >= r.MinX && x <= r.MaxX && y >= r.MinY && y <= r.MaxY
+}
+
+// Zone is a named area of a map.
+type Zone struct {
+	// Name describes the zone without its side, such as "bottom gate".
+	Name string `json:"name"`
+	Kind Kind   `json:"kind"`
+	// Side is the team whose side of the map the zone is on, or empty for zones in the middle.
+	// A team's hive and queen spawn are on its side.
+	Side event.Team `json:"side,omitempty"`
+	// Buff is the buff given by a gate.
+	Buff event.Buff `json:"buff,omitempty"`
+	Rect Rect       `json:"rect"`
+}
+
+// Geometry is the zones of a map.  When zones overlap, the first one listed wins.
+type Geometry struct {
+	Zones []Zone `json:"zones"`
+}
+
+// String describes the zone, such as "gold hive", "blue-side bottom gate" or "middle gate".
+// The field is described by its side, or as "middle".
+func (z Zone) String() string {
+	side := lower(z.Side)
+	switch {
+	case z.Kind == Field && side == "":
+		return "middle"
+	case z.Kind == Field:
+		return side + " side"
+	case side == "":
+		return z.Name
+	case z.Kind == Hive || z.Kind == QueenSpawn:
+		return side + " " + z.Name
+	}
+	return side + "-side " + z.Name
+}
+
+// Atlas is the geometry of each map.
+type Atlas map[event.Map]Geometry
+
+// Builtin returns a copy of the built-in geometry of each map.
+func Builtin() Atlas {
+	a := make(Atlas, len(builtin))
+	for m, g := range builtin {
+		a[m] = Geometry{Zones: append([]Zone(nil), g.Zones...)}
+	}
+	return a
+}
+
+// ReadAtlas decodes an atlas as JSON, keyed by map name, and adds it to the built-in geometry.
+// Maps in the JSON replace the built-in geometry for that map.
+func ReadAtlas(r io.Reader) (Atlas, error) {
+	var maps Atlas
+	err := json.NewDecoder(r).Decode(&maps)
+	if err != nil {
+		return nil, err
+	}
+	a := Builtin()
+	for m, g := range maps {
+		a[m] = g
+	}
+	return a, nil
+}
+
+// Locate returns the zone a position is in, on a map played in orientation o.
+// Positions outside every zone are in the field, which is split into the blue side, the middle and the gold side.
+func (a Atlas) Locate(m event.Map, o event.CabOrientation, x, y int) Zone {
+	if o == event.GoldOnLeft {
+		x = Width - x
+	}
+	for _, z := range a[m].Zones {
+		if z.Rect.Contains(x, y) {
+			return z
+		}
+	}
+	field := Zone{Name: string(Field), Kind: Field}
+	switch {
+	case x < Width/2-middle:
+		field.Side = event.Blue
+	case x > Width/2+middle:
+		field.Side = event.Gold
+	}
+	return field
+}
+
+// LocateEvent returns the zone an event occurred in, on a map played in orientation o, and false for events
+// without a position.
+func (a Atlas) LocateEvent(m event.Map, o event.CabOrientation, ev event.Event) (Zone, bool) {
+	x, y, ok := event.Position(ev)
+	if !ok {
+		return Zone{}, false
+	}
+	return a.Locate(m, o, x, y), true
+}
+
+// Tracker locates the events of a stream, keeping track of the map and orientation of the current game.
+type Tracker struct {
+	Atlas       Atlas
+	Map         event.Map
+	Orientation event.CabOrientation
+}
+
+// NewTracker returns a *Tracker using atlas.
+func NewTracker(atlas Atlas) *Tracker {
+	return &Tracker{Atlas: atlas}
+}
+
+// Add adds the next event of the stream, which updates the map and orientation when it's a gamestart or gameend.
+func (t *Tracker) Add(ev event.Event) {
+	switch v := ev.(type) {
+	case event.GameStart:
+		t.Map, t.Orientation = v.Map, v.Orientation
+	case event.GameEnd:
+		t.Map, t.Orientation = v.Map, v.Orientation
+	}
+}
+
+// Locate returns the zone an event of the current game occurred in, and false for events without a position.
+func (t *Tracker) Locate(ev event.Event) (Zone, bool) {
+	return t.Atlas.LocateEvent(t.Map, t.Orientation, ev)
+}
+
+// lower returns the name of a team in lower case.
+func lower(t event.Team) string {
+	switch t {
+	case event.Gold:
+		return "gold"
+	case event.Blue:
+		return "blue"
+	case event.Red:
+		return "red"
+	}
+	return ""
+}
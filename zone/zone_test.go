@@ -0,0 +1,125 @@
+package zone
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/rickyninja/kqstat/event"
+	"github.com/rickyninja/kqstat/internal/testutil"
+	"github.com/rickyninja/kqstat/record"
+)
+
+func TestZone_String(t *testing.T) {
+	a := Builtin()
+	tests := []struct {
+		m    event.Map
+		o    event.CabOrientation
+		x, y int
+		want string
+	}{
+		{event.Day, event.BlueOnLeft, 1360, 260, "gold-side bottom gate"},
+		{event.Day, event.BlueOnLeft, 960, 500, "middle gate"},
+		{event.Dusk, event.BlueOnLeft, 310, 620, "blue-side top gate"},
+		{event.Night, event.BlueOnLeft, 1750, 100, "gold hive"},
+		{event.Night, event.BlueOnLeft, 960, 491, "snail track"},
+		{event.BonusMilitary, event.BlueOnLeft, 1700, 100, "gold hive"},
+		{event.BonusSnail, event.GoldOnLeft, 1700, 100, "blue hive"},
+		{event.BonusSnail, event.BlueOnLeft, 700, 1040, "blue queen spawn"},
+		{event.BonusSnail, event.BlueOnLeft, 960, 20, "snail track"},
+		{event.BonusMilitary, event.BlueOnLeft, 960, 500, "middle"},
+		{event.Day, event.BlueOnLeft, 100, 500, "blue side"},
+		{event.Day, event.BlueOnLeft, 1000, 700, "middle"},
+		// The stats service mirrors positions when gold is on the left.
+		{event.Night, event.GoldOnLeft, 1750, 100, "blue hive"},
+		{event.Dusk, event.GoldOnLeft, 310, 620, "gold-side top gate"},
+		{event.Map("map_unknown"), event.BlueOnLeft, 1800, 500, "gold side"},
+	}
+	for _, tt := range tests {
+		if got := a.Locate(tt.m, tt.o, tt.x, tt.y).String(); got != tt.want {
+			t.Errorf("%s %s %d,%d: got %s want %s", tt.m, tt.o, tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestTracker(t *testing.T) {
+	fd, err := os.Open(testutil.BB3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	r := record.NewReader(fd)
+	tr := NewTracker(Builtin())
+	var gates, gatesFound, deposits, hives, snails, onTrack int
+	for {
+		env, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		tr.Add(env.Event)
+		z, ok := tr.Locate(env.Event)
+		if !ok {
+			continue
+		}
+		// The recording begins partway through a game with an unknown map.
+		if tr.Map == "" {
+			continue
+		}
+		switch v := env.Event.(type) {
+		case event.UseMaiden:
+			gates++
+			if z.Kind == Gate && z.Buff == v.Buff {
+				gatesFound++
+			}
+		case event.BerryDeposit:
+			deposits++
+			if z.Kind == Hive && z.Side == v.Who.Team() {
+				hives++
+			}
+		case event.GetOnSnail, event.GetOffSnail, event.SnailEat, event.SnailEscape:
+			snails++
+			if z.Kind == SnailTrack {
+				onTrack++
+			}
+		}
+	}
+	for _, c := range []struct {
+		name         string
+		found, total int
+	}{
+		{"gates", gatesFound, gates},
+		{"hives", hives, deposits},
+		{"snail track", onTrack, snails},
+	} {
+		if c.total == 0 {
+			t.Fatalf("no events for %s", c.name)
+		}
+		// A few events are sent after the next game has begun, so they're on the wrong map.
+		if float64(c.found) < 0.95*float64(c.total) {
+			t.Errorf("located %d of %d events in the %s", c.found, c.total, c.name)
+		}
+	}
+}
+
+func TestReadAtlas(t *testing.T) {
+	a, err := ReadAtlas(strings.NewReader(`{"map_night": {"zones": [{"name": "hive", "kind": "hive", "side": "Blue", "rect": {"min_x": 0, "min_y": 0, "max_x": 500, "max_y": 500}}]}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := a.Locate(event.Night, event.BlueOnLeft, 400, 400).String(); got != "blue hive" {
+		t.Errorf("got %s want blue hive", got)
+	}
+	if got := a.Locate(event.Night, event.BlueOnLeft, 1220, 260).String(); got != "gold side" {
+		t.Errorf("got %s for a replaced gate want gold side", got)
+	}
+	if got := a.Locate(event.Day, event.BlueOnLeft, 960, 500).String(); got != "middle gate" {
+		t.Errorf("got %s want the built-in middle gate", got)
+	}
+	if got := Builtin().Locate(event.Night, event.BlueOnLeft, 400, 400).String(); got == "blue hive" {
+		t.Error("ReadAtlas changed the built-in geometry")
+	}
+}